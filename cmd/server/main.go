@@ -2,23 +2,26 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"io/fs"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"spahtmx/internal/adapter/database"
+	"spahtmx/internal/adapter/mail"
 	"spahtmx/internal/adapter/web"
 	"spahtmx/internal/app"
 	"spahtmx/internal/config"
 	"spahtmx/internal/domain"
+	"strings"
 	"syscall"
 	"time"
 
 	_ "github.com/joho/godotenv/autoload"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/jackc/pgx/v5"
@@ -31,6 +34,28 @@ import (
 
 func main() {
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	tokenService, err := newTokenService(cfg)
+	if err != nil {
+		slog.Error("Invalid JWT keys", "error", err)
+		os.Exit(1)
+	}
+
+	secretBox, err := newSecretBox(cfg)
+	if err != nil {
+		slog.Error("Invalid TOTP encryption key", "error", err)
+		os.Exit(1)
+	}
+
+	ipExtractor, err := newIPExtractor(cfg)
+	if err != nil {
+		slog.Error("Invalid trusted proxies", "error", err)
+		os.Exit(1)
+	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
@@ -46,16 +71,36 @@ func main() {
 		DB: db,
 	}
 
-	userService := app.NewUserService(userRepo)
+	sessionRepo := &database.SessionBunRepository{
+		DB: db,
+	}
+
+	auditService := app.NewAuditService(&database.AuditBunRepository{DB: db})
+	lockoutService := app.NewLockoutService(&database.LoginThrottleBunRepository{DB: db}, userRepo, auditService)
+
+	twoFactorService := app.NewTwoFactorService(&database.TwoFactorBunRepository{DB: db}, userRepo, sessionRepo, secretBox, auditService, cfg.TOTPIssuer)
+
+	authService := app.NewAuthService(userRepo, sessionRepo, tokenService, lockoutService, twoFactorService, app.SessionSettings{
+		AccessTokenTTL:       cfg.AccessTokenTTL,
+		RefreshTokenTTL:      cfg.RefreshTokenTTL,
+		RefreshReuseInterval: cfg.RefreshReuseInterval,
+	})
+	userService := app.NewUserService(userRepo, authService)
 
 	prizeRepo := &database.PrizeBunRepository{
 		DB: db,
 	}
 
 	prizeService := app.NewPrizeService(prizeRepo)
-	authService := app.NewAuthService(userRepo)
 
-	e := initWeb(userService, prizeService, authService, cfg)
+	pageViewService := app.NewPageViewService(&database.PageViewBunRepository{DB: db})
+	go pageViewService.Run(ctx, cfg.PageViewFlushInterval)
+
+	mailer := newMailer(cfg)
+	registrationService := app.NewRegistrationService(userRepo, userService, tokenService, mailer, cfg.BaseURL)
+	passwordResetService := app.NewPasswordResetService(userRepo, &database.PasswordResetBunRepository{DB: db}, authService, mailer, cfg.BaseURL)
+
+	e := initWeb(userService, prizeService, authService, pageViewService, lockoutService, auditService, registrationService, passwordResetService, twoFactorService, ipExtractor, cfg)
 
 	// Démarrage du serveur dans une goroutine
 	go func() {
@@ -76,6 +121,9 @@ func main() {
 	if err := e.Shutdown(shutdownCtx); err != nil {
 		slog.Error("Server forced to shutdown", "error", err)
 	}
+	if err := pageViewService.Flush(shutdownCtx); err != nil {
+		slog.Error("Failed to flush page views", "error", err)
+	}
 
 	slog.Info("Server exiting")
 }
@@ -126,8 +174,19 @@ func initDB(ctx context.Context, cfg *config.Config) *bun.DB {
 func createSchema(ctx context.Context, db *bun.DB, purge bool) error {
 	models := []interface{}{
 		(*database.UserBun)(nil),
+		(*database.SessionBun)(nil),
+		(*database.RefreshTokenBun)(nil),
+		(*database.LoginThrottleBun)(nil),
+		(*database.AuditEntryBun)(nil),
+		(*database.PasswordResetBun)(nil),
+		(*database.TwoFactorBun)(nil),
+		(*database.RecoveryCodeBun)(nil),
+		(*database.RolePolicyBun)(nil),
 		(*database.PrizeBun)(nil),
 		(*database.LaureateBun)(nil),
+		(*database.PrizeLaureateBun)(nil),
+		(*database.NotAwardedBun)(nil),
+		(*database.PageViewBun)(nil),
 	}
 
 	if purge {
@@ -146,7 +205,15 @@ func createSchema(ctx context.Context, db *bun.DB, purge bool) error {
 		}
 	}
 
-	return nil
+	// Les tables existantes ne sont pas modifiées par CreateTable
+	if err := database.MigrateUsers(ctx, db); err != nil {
+		return err
+	}
+	if err := database.MigratePrizes(ctx, db); err != nil {
+		return err
+	}
+
+	return database.CreateSearchIndexes(ctx, db)
 }
 
 func seedUserDatabase(ctx context.Context, db *bun.DB) {
@@ -157,9 +224,9 @@ func seedUserDatabase(ctx context.Context, db *bun.DB) {
 	hashedPassword := string(bytes)
 
 	us := []database.UserBun{
-		{Username: "alice", Password: hashedPassword, Email: "alice@fake.com", Status: true},
-		{Username: "bob", Password: hashedPassword, Email: "bob@fake.com", Status: false},
-		{Username: "charlie", Password: hashedPassword, Email: "charlie@fake.com", Status: true},
+		{Username: "alice", Password: hashedPassword, Email: "alice@fake.com", Status: string(domain.StatusActive), Role: string(domain.RoleAdmin)},
+		{Username: "bob", Password: hashedPassword, Email: "bob@fake.com", Status: string(domain.StatusDisabled), StatusReason: domain.DisabledByAdminReason, Role: string(domain.RoleViewer)},
+		{Username: "charlie", Password: hashedPassword, Email: "charlie@fake.com", Status: string(domain.StatusActive), Role: string(domain.RoleViewer)},
 	}
 
 	for _, u := range us {
@@ -175,33 +242,123 @@ func seedUserDatabase(ctx context.Context, db *bun.DB) {
 
 func seedPrizeDatabase(ctx context.Context, db *bun.DB) {
 
-	data, err := os.ReadFile("nobel-prize.json")
+	f, err := os.Open("nobel-prize.json")
+	if err != nil {
+		slog.Error("failed to read nobel-prize.json", "error", err)
+		return
+	}
+	defer f.Close()
+
+	importer := app.NewImportService(&database.PrizeBunRepository{DB: db})
+	report, err := importer.ImportPrizes(ctx, f)
 	if err != nil {
-		slog.Error("failed to read novel-prize.json", "error", err)
+		slog.Error("failed to import prizes", "error", err)
+		return
 	}
 
-	var pl domain.PrizeList
-	if err := json.Unmarshal(data, &pl); err != nil {
-		slog.Error("failed to unmarshal JSON", "error", err)
+	for _, issue := range report.Rejected {
+		slog.Warn("rejected prize record", "index", issue.Index, "year", issue.Year, "category", issue.Category, "error", issue.Err)
+	}
+	for _, issue := range report.Flagged {
+		slog.Warn("inconsistent prize record", "index", issue.Index, "year", issue.Year, "category", issue.Category, "error", issue.Err)
 	}
 
-	prizes := pl.Prizes
-	fmt.Printf("Loaded %d prizes\n", len(prizes))
+	fmt.Printf("Loaded %d prizes\n", report.Loaded)
+	fmt.Printf("Inserted %d documents (%d distinct laureates, %d organisations), %d not awarded, rejected %d, flagged %d\n",
+		report.Inserted, report.Laureates, report.Organisations, report.NotAwarded, len(report.Rejected), len(report.Flagged))
+}
 
-	prizeRepo := database.PrizeBunRepository{DB: db}
-	inserted := 0
-	for _, p := range prizes {
-		if err := prizeRepo.Save(ctx, p); err != nil {
-			slog.Error("failed to insert prize", "error", err)
-			continue
+// newTokenService builds the access token service from the configured keys.
+func newTokenService(cfg *config.Config) (*app.TokenService, error) {
+	keys := make([]app.SigningKey, 0, len(cfg.JWTKeys))
+	for i, k := range cfg.JWTKeys {
+		var key app.SigningKey
+		var err error
+		switch k.Algorithm {
+		case "HS256":
+			key, err = app.NewHMACKey(k.ID, k.Secret)
+		case "EdDSA":
+			var seed []byte
+			seed, err = base64.StdEncoding.DecodeString(k.Secret)
+			if err == nil {
+				key, err = app.NewEd25519Key(k.ID, seed)
+			}
+		default:
+			err = fmt.Errorf("key %d: expected kid:HS256:secret or kid:EdDSA:base64-seed", i+1)
+		}
+		if err != nil {
+			return nil, err
 		}
-		inserted++
+		keys = append(keys, key)
 	}
+	return app.NewTokenService(cfg.JWTIssuer, cfg.JWTAudience, keys)
+}
 
-	fmt.Printf("Inserted %d documents\n", inserted)
+// newIPExtractor returns how the client address is found. X-Forwarded-For
+// can be set by any client, so it is only read from the configured proxies;
+// without them, the address of the connection is used.
+func newIPExtractor(cfg *config.Config) (echo.IPExtractor, error) {
+	if len(cfg.TrustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, proxy := range cfg.TrustedProxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, echo.TrustIPRange(ipNet))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
 }
 
-func AuthMiddleware(cfg *config.Config) echo.MiddlewareFunc {
+// newSecretBox builds the box that encrypts two-factor secrets. Without a
+// configured key, which only happens outside production, the key is derived
+// from the JWT secret.
+func newSecretBox(cfg *config.Config) (*app.SecretBox, error) {
+	if cfg.TOTPKey == "" {
+		slog.Warn("No TOTP_ENCRYPTION_KEY configured, deriving one from JWT_SECRET")
+		key := sha256.Sum256([]byte(cfg.JWTSecret))
+		return app.NewSecretBox(key[:])
+	}
+	key, err := base64.StdEncoding.DecodeString(cfg.TOTPKey)
+	if err != nil {
+		return nil, err
+	}
+	return app.NewSecretBox(key)
+}
+
+// newMailer sends emails through the configured SMTP server, or only logs
+// them when there is none, which Config.Validate refuses in production.
+func newMailer(cfg *config.Config) domain.Mailer {
+	if cfg.SMTPHost == "" {
+		return &mail.LogMailer{Dir: cfg.MailDir, From: cfg.MailFrom}
+	}
+	return &mail.SMTPMailer{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.MailFrom,
+	}
+}
+
+// AuthMiddleware requires a valid session of an active user, renewing an
+// expired access token with the refresh token, and makes the user and
+// session available to the next handlers under web.ContextUser and
+// web.ContextSession.
+func AuthMiddleware(authService *app.AuthService) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			redirect := func() error {
@@ -212,28 +369,25 @@ func AuthMiddleware(cfg *config.Config) echo.MiddlewareFunc {
 				return c.Redirect(http.StatusSeeOther, "/login")
 			}
 
-			cookie, err := c.Cookie("session")
-			if err != nil || cookie.Value == "" {
-				return redirect()
-			}
-
-			token, err := jwt.Parse(cookie.Value, func(token *jwt.Token) (interface{}, error) {
-				return []byte(cfg.JWTSecret), nil
-			})
-
-			if err != nil || !token.Valid {
+			// Le jeton d'accès expiré est renouvelé de façon transparente
+			user, session, err := web.Authenticate(c, authService)
+			if err != nil {
 				return redirect()
 			}
 
+			c.Set(web.ContextUser, user)
+			c.Set(web.ContextSession, session)
 			return next(c)
 		}
 	}
 }
 
-func initWeb(userService *app.UserService, prizeService *app.PrizeService, authService *app.AuthService, cfg *config.Config) *echo.Echo {
-	handler := web.NewHandler(userService, prizeService, authService, cfg)
+func initWeb(userService *app.UserService, prizeService *app.PrizeService, authService *app.AuthService, pageViewService *app.PageViewService, lockoutService *app.LockoutService, auditService *app.AuditService, registrationService *app.RegistrationService, passwordResetService *app.PasswordResetService, twoFactorService *app.TwoFactorService, ipExtractor echo.IPExtractor, cfg *config.Config) *echo.Echo {
+	handler := web.NewHandler(userService, prizeService, authService, pageViewService, lockoutService, auditService, registrationService, passwordResetService, twoFactorService, cfg)
 
 	e := echo.New()
+	// L'adresse du client sert à limiter les tentatives de connexion
+	e.IPExtractor = ipExtractor
 	e.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
 		LogStatus:   true,
 		LogURI:      true,
@@ -252,15 +406,48 @@ func initWeb(userService *app.UserService, prizeService *app.PrizeService, authS
 	e.Use(middleware.Gzip())
 	e.Use(middleware.Recover()) // Prevents server crashes on panics
 	e.Use(middleware.Secure())  // Adds secure headers (XSS, Content-Type sniffing, etc.)
+	e.Use(web.PageViewMiddleware(pageViewService))
+
+	// Seuls les administrateurs gèrent les utilisateurs et consultent l'analytique
+	auth := AuthMiddleware(authService)
+	admin := web.RequireRole(domain.RoleAdmin)
 
 	e.GET(web.RouteIndex, handler.HandleIndexPage)
 	e.GET(web.RoutePrize, handler.HandlePrizePage)
-	e.GET(web.RouteAdmin, handler.HandleAdminPage, AuthMiddleware(cfg))
+	e.GET(web.RoutePrizeDetail, handler.HandlePrizeDetailPage)
+	e.GET(web.RouteLaureate, handler.HandleLaureatePage)
+	e.GET(web.RouteStats, handler.HandleStatsPage)
+	e.GET(web.RouteAdmin, handler.HandleAdminPage, auth, admin)
+	e.GET(web.RouteAnalytics, handler.HandleAnalyticsPage, auth, admin)
+	e.POST(web.RouteUsers, handler.HandleUserCreate, auth, admin)
+	e.GET(web.RouteUser, handler.HandleUserRow, auth, admin)
+	e.PUT(web.RouteUser, handler.HandleUserUpdate, auth, admin)
+	e.DELETE(web.RouteUser, handler.HandleUserDelete, auth, admin)
+	e.GET(web.RouteUserEdit, handler.HandleUserEditForm, auth, admin)
+	e.POST(web.RouteUserUnlock, handler.HandleUserUnlock, auth, admin)
+	e.POST(web.RouteLoginBlocks, handler.HandleLoginUnblock, auth, admin)
+	e.POST(web.RouteRolePolicies, handler.HandleRolePolicy, auth, admin)
 	e.GET(web.RouteAbout, handler.HandleAboutPage)
 	e.GET(web.RouteLogin, handler.HandleLoginPage)
 	e.POST(web.RouteLogin, handler.HandleLoginPost)
+	e.POST(web.RouteLoginCode, handler.HandleLoginCodePost)
 	e.POST(web.RouteLogout, handler.HandleLogout)
-	e.POST(web.RouteSwitch, handler.HandleUserStatusSwitch, AuthMiddleware(cfg))
+	e.GET(web.RouteRegister, handler.HandleRegisterPage)
+	e.POST(web.RouteRegister, handler.HandleRegisterPost)
+	e.GET(web.RouteVerifyEmail, handler.HandleVerifyEmail)
+	e.GET(web.RouteForgot, handler.HandleForgotPasswordPage)
+	e.POST(web.RouteForgot, handler.HandleForgotPasswordPost)
+	e.GET(web.RouteReset, handler.HandleResetPasswordPage)
+	e.POST(web.RouteReset, handler.HandleResetPasswordPost)
+	e.GET(web.RouteSessions, handler.HandleSessionsPage, auth)
+	e.POST(web.RouteLogoutAll, handler.HandleLogoutAll, auth)
+	e.DELETE(web.RouteSession, handler.HandleSessionRevoke, auth)
+	e.GET(web.RouteTwoFactor, handler.HandleTwoFactorPage, auth)
+	e.POST(web.RouteTwoFactorEnroll, handler.HandleTwoFactorEnroll, auth)
+	e.POST(web.RouteTwoFactorConfirm, handler.HandleTwoFactorConfirm, auth)
+	e.POST(web.RouteRecoveryCodes, handler.HandleRecoveryCodes, auth)
+	e.POST(web.RouteTwoFactorDisable, handler.HandleTwoFactorDisable, auth)
+	e.POST(web.RouteSwitch, handler.HandleUserStatusSwitch, auth, admin)
 	e.GET(web.RouteStatus, func(c echo.Context) error {
 		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
 	})
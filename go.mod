@@ -17,6 +17,7 @@ require (
 	github.com/uptrace/bun/dialect/pgdialect v1.2.18
 	github.com/uptrace/bun/extra/bundebug v1.2.18
 	golang.org/x/crypto v0.50.0
+	rsc.io/qr v0.2.0
 )
 
 require (
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"spahtmx/internal/domain"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type AuditBunRepository struct {
+	DB *bun.DB
+}
+
+type AuditEntryBun struct {
+	bun.BaseModel `bun:"table:audit_log"`
+
+	ID     int64     `bun:"id,pk,autoincrement"`
+	At     time.Time `bun:"at,notnull"`
+	Actor  string    `bun:"actor"`
+	Action string    `bun:"action,notnull"`
+	Target string    `bun:"target"`
+	Detail string    `bun:"detail"`
+}
+
+func ToAuditEntryDomain(e AuditEntryBun) domain.AuditEntry {
+	return domain.AuditEntry{
+		ID:     e.ID,
+		At:     e.At,
+		Actor:  e.Actor,
+		Action: domain.AuditAction(e.Action),
+		Target: e.Target,
+		Detail: e.Detail,
+	}
+}
+
+func FromAuditEntryDomain(e domain.AuditEntry) *AuditEntryBun {
+	return &AuditEntryBun{
+		ID:     e.ID,
+		At:     e.At,
+		Actor:  e.Actor,
+		Action: string(e.Action),
+		Target: e.Target,
+		Detail: e.Detail,
+	}
+}
+
+func (r *AuditBunRepository) AddAuditEntry(ctx context.Context, entry domain.AuditEntry) error {
+	_, err := r.DB.NewInsert().Model(FromAuditEntryDomain(entry)).Exec(ctx)
+	return err
+}
+
+// GetAuditEntries returns the latest entries, most recent first.
+func (r *AuditBunRepository) GetAuditEntries(ctx context.Context, limit int) ([]domain.AuditEntry, error) {
+	var entries []AuditEntryBun
+	err := r.DB.NewSelect().Model(&entries).Order("at DESC", "id DESC").Limit(limit).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	domainEntries := make([]domain.AuditEntry, 0, len(entries))
+	for _, e := range entries {
+		domainEntries = append(domainEntries, ToAuditEntryDomain(e))
+	}
+	return domainEntries, nil
+}
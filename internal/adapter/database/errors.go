@@ -0,0 +1,51 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"spahtmx/internal/domain"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// parseID converts a path identifier into a primary key, rejecting anything
+// that is not a positive integer as invalid input.
+func parseID(id string) (int64, error) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, domain.ErrInvalidInput
+	}
+	return n, nil
+}
+
+// translateNotFound maps sql.ErrNoRows to the given domain error and leaves
+// every other error untouched.
+func translateNotFound(err error, notFound error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound
+	}
+	return err
+}
+
+// checkAffected reports notFound when a write statement matched no row.
+func checkAffected(res sql.Result, notFound error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
+
+// uniqueViolation returns the name of the unique constraint the error
+// violates, or "" for any other error.
+func uniqueViolation(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return pgErr.ConstraintName
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"spahtmx/internal/domain"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type LoginThrottleBunRepository struct {
+	DB *bun.DB
+}
+
+type LoginThrottleBun struct {
+	bun.BaseModel `bun:"table:login_throttles"`
+
+	Key           string    `bun:"key,pk"`
+	Failures      int       `bun:"failures,notnull"`
+	LastFailureAt time.Time `bun:"last_failure_at,notnull"`
+	BlockedUntil  time.Time `bun:"blocked_until,nullzero"`
+}
+
+func ToLoginThrottleDomain(t LoginThrottleBun) domain.LoginThrottle {
+	return domain.LoginThrottle{
+		Key:           t.Key,
+		Failures:      t.Failures,
+		LastFailureAt: t.LastFailureAt,
+		BlockedUntil:  t.BlockedUntil,
+	}
+}
+
+func toLoginThrottlesDomain(throttles []LoginThrottleBun) []domain.LoginThrottle {
+	domainThrottles := make([]domain.LoginThrottle, 0, len(throttles))
+	for _, t := range throttles {
+		domainThrottles = append(domainThrottles, ToLoginThrottleDomain(t))
+	}
+	return domainThrottles
+}
+
+func (r *LoginThrottleBunRepository) GetLoginThrottles(ctx context.Context, keys []string) ([]domain.LoginThrottle, error) {
+	var throttles []LoginThrottleBun
+	err := r.DB.NewSelect().Model(&throttles).Where("key IN (?)", bun.In(keys)).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toLoginThrottlesDomain(throttles), nil
+}
+
+// AddLoginFailure counts a failed login in a single statement, so that
+// concurrent attempts are all counted. Failures older than since are
+// forgotten and counting starts again.
+func (r *LoginThrottleBunRepository) AddLoginFailure(ctx context.Context, key string, at, since time.Time) (domain.LoginThrottle, error) {
+	throttle := LoginThrottleBun{
+		Key:           key,
+		Failures:      1,
+		LastFailureAt: at,
+	}
+	_, err := r.DB.NewInsert().Model(&throttle).
+		On("CONFLICT (key) DO UPDATE").
+		Set("failures = CASE WHEN ?TableAlias.last_failure_at < ? THEN 1 ELSE ?TableAlias.failures + 1 END", since).
+		Set("last_failure_at = EXCLUDED.last_failure_at").
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return domain.LoginThrottle{}, err
+	}
+	return ToLoginThrottleDomain(throttle), nil
+}
+
+func (r *LoginThrottleBunRepository) BlockLogin(ctx context.Context, key string, until time.Time) error {
+	_, err := r.DB.NewUpdate().Model((*LoginThrottleBun)(nil)).Set("blocked_until = ?", until).Where("key = ?", key).Exec(ctx)
+	return err
+}
+
+func (r *LoginThrottleBunRepository) ClearLoginThrottle(ctx context.Context, key string) error {
+	_, err := r.DB.NewDelete().Model((*LoginThrottleBun)(nil)).Where("key = ?", key).Exec(ctx)
+	return err
+}
+
+// GetBlockedLogins returns the usernames and addresses whose logins are
+// refused at the given time, those blocked the longest first.
+func (r *LoginThrottleBunRepository) GetBlockedLogins(ctx context.Context, now time.Time) ([]domain.LoginThrottle, error) {
+	var throttles []LoginThrottleBun
+	err := r.DB.NewSelect().Model(&throttles).
+		Where("blocked_until > ?", now).
+		Order("blocked_until DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toLoginThrottlesDomain(throttles), nil
+}
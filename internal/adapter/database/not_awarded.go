@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"spahtmx/internal/domain"
+
+	"github.com/uptrace/bun"
+)
+
+// NotAwardedBun is a category and year for which no prize was awarded.
+type NotAwardedBun struct {
+	bun.BaseModel `bun:"table:not_awarded"`
+
+	Category string `bun:"category,pk"`
+	Year     int    `bun:"year,pk"`
+	Reason   string `bun:"reason"`
+}
+
+func ToNotAwardedDomain(n NotAwardedBun) domain.NotAwarded {
+	return domain.NotAwarded{
+		Category: domain.Category(n.Category),
+		Year:     n.Year,
+		Reason:   n.Reason,
+	}
+}
+
+func FromNotAwardedDomain(n domain.NotAwarded) *NotAwardedBun {
+	return &NotAwardedBun{
+		Category: string(n.Category),
+		Year:     n.Year,
+		Reason:   n.Reason,
+	}
+}
+
+// SaveNotAwarded records that a prize was not awarded, replacing the reason
+// if the category and year are already known.
+func (r *PrizeBunRepository) SaveNotAwarded(ctx context.Context, notAwarded domain.NotAwarded) error {
+	_, err := r.DB.NewInsert().Model(FromNotAwardedDomain(notAwarded)).
+		On("CONFLICT (category, year) DO UPDATE").
+		Set("reason = EXCLUDED.reason").
+		Exec(ctx)
+	return err
+}
+
+// FindNotAwarded returns the prizes not awarded in the categories and years
+// selected by the criteria, most recent first. Other filters do not apply.
+func (r *PrizeBunRepository) FindNotAwarded(ctx context.Context, criteria domain.PrizeCriteria) ([]domain.NotAwarded, error) {
+
+	var rows []NotAwardedBun
+	q := r.DB.NewSelect().Model(&rows).Order("year DESC", "category ASC")
+	if len(criteria.Categories) > 0 {
+		q = q.Where("category IN (?)", bun.In(criteria.Categories))
+	}
+	if criteria.YearFrom != 0 {
+		q = q.Where("year >= ?", criteria.YearFrom)
+	}
+	if criteria.YearTo != 0 {
+		q = q.Where("year <= ?", criteria.YearTo)
+	}
+	if err := q.Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	notAwarded := make([]domain.NotAwarded, 0, len(rows))
+	for _, n := range rows {
+		notAwarded = append(notAwarded, ToNotAwardedDomain(n))
+	}
+	return notAwarded, nil
+}
+
+func (r *PrizeBunRepository) GetNotAwarded(ctx context.Context, criteria domain.PrizeCriteria) ([]domain.NotAwarded, error) {
+	return r.FindNotAwarded(ctx, criteria)
+}
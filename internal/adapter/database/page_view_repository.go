@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"spahtmx/internal/domain"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type PageViewBunRepository struct {
+	DB *bun.DB
+}
+
+// PageViewBun counts the page views of a day (UTC) that share a route, load
+// type, status, latency bucket and referrer host.
+type PageViewBun struct {
+	bun.BaseModel `bun:"table:page_view_stats"`
+
+	Day          time.Time `bun:"day,pk,type:date"`
+	Route        string    `bun:"route,pk"`
+	HTMX         bool      `bun:"htmx,pk"`
+	Status       int       `bun:"status,pk"`
+	Latency      string    `bun:"latency,pk"`
+	ReferrerHost string    `bun:"referrer_host,pk"`
+	Count        int64     `bun:"count,notnull"`
+}
+
+// AddPageViews adds the counts to the stored ones, creating the missing rows.
+func (r *PageViewBunRepository) AddPageViews(ctx context.Context, counts []domain.PageViewCount) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	rows := make([]PageViewBun, 0, len(counts))
+	for _, c := range counts {
+		rows = append(rows, PageViewBun{
+			Day:          c.Day,
+			Route:        c.Route,
+			HTMX:         c.HTMX,
+			Status:       c.Status,
+			Latency:      string(c.Latency),
+			ReferrerHost: c.ReferrerHost,
+			Count:        int64(c.Count),
+		})
+	}
+
+	_, err := r.DB.NewInsert().Model(&rows).
+		On("CONFLICT (day, route, htmx, status, latency, referrer_host) DO UPDATE").
+		Set("count = ?TableAlias.count + EXCLUDED.count").
+		Exec(ctx)
+	return err
+}
+
+func (r *PageViewBunRepository) GetPageViewTotals(ctx context.Context, today time.Time) (domain.PageViewTotals, error) {
+	var totals struct {
+		Today    int `bun:"today"`
+		LastWeek int `bun:"last_week"`
+		AllTime  int `bun:"all_time"`
+	}
+	err := r.DB.NewSelect().Model((*PageViewBun)(nil)).
+		ColumnExpr("coalesce(sum(count) FILTER (WHERE day = ?), 0) AS today", today).
+		ColumnExpr("coalesce(sum(count) FILTER (WHERE day >= ?), 0) AS last_week", today.AddDate(0, 0, -6)).
+		ColumnExpr("coalesce(sum(count), 0) AS all_time").
+		Scan(ctx, &totals)
+	if err != nil {
+		return domain.PageViewTotals{}, err
+	}
+
+	return domain.PageViewTotals{Today: totals.Today, LastWeek: totals.LastWeek, AllTime: totals.AllTime}, nil
+}
+
+// GetTopRoutes returns the most viewed routes since the day, most viewed first.
+func (r *PageViewBunRepository) GetTopRoutes(ctx context.Context, since time.Time, limit int) ([]domain.RouteViews, error) {
+	var rows []struct {
+		Route  string `bun:"route"`
+		Views  int    `bun:"views"`
+		HTMX   int    `bun:"htmx"`
+		Errors int    `bun:"errors"`
+	}
+	err := r.DB.NewSelect().Model((*PageViewBun)(nil)).
+		Column("route").
+		ColumnExpr("sum(count) AS views").
+		ColumnExpr("coalesce(sum(count) FILTER (WHERE htmx), 0) AS htmx").
+		ColumnExpr("coalesce(sum(count) FILTER (WHERE status >= 400), 0) AS errors").
+		Where("day >= ?", since).
+		Group("route").
+		OrderExpr("views DESC, route").
+		Limit(limit).
+		Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]domain.RouteViews, 0, len(rows))
+	for _, row := range rows {
+		routes = append(routes, domain.RouteViews{Route: row.Route, Views: row.Views, HTMX: row.HTMX, Errors: row.Errors})
+	}
+	return routes, nil
+}
+
+// GetDailyViews returns the views of each day since the given one that has
+// any, in chronological order.
+func (r *PageViewBunRepository) GetDailyViews(ctx context.Context, since time.Time) ([]domain.DayViews, error) {
+	var rows []struct {
+		Day  time.Time `bun:"day"`
+		Full int       `bun:"full_loads"`
+		HTMX int       `bun:"htmx"`
+	}
+	err := r.DB.NewSelect().Model((*PageViewBun)(nil)).
+		Column("day").
+		ColumnExpr("coalesce(sum(count) FILTER (WHERE NOT htmx), 0) AS full_loads").
+		ColumnExpr("coalesce(sum(count) FILTER (WHERE htmx), 0) AS htmx").
+		Where("day >= ?", since).
+		Group("day").
+		Order("day").
+		Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	days := make([]domain.DayViews, 0, len(rows))
+	for _, row := range rows {
+		days = append(days, domain.DayViews{Day: row.Day, Full: row.Full, HTMX: row.HTMX})
+	}
+	return days, nil
+}
+
+// GetTopReferrers returns the referrer hosts that brought the most views
+// since the day.
+func (r *PageViewBunRepository) GetTopReferrers(ctx context.Context, since time.Time, limit int) ([]domain.ReferrerViews, error) {
+	var rows []struct {
+		Host  string `bun:"referrer_host"`
+		Views int    `bun:"views"`
+	}
+	err := r.DB.NewSelect().Model((*PageViewBun)(nil)).
+		Column("referrer_host").
+		ColumnExpr("sum(count) AS views").
+		Where("day >= ?", since).
+		Group("referrer_host").
+		OrderExpr("views DESC, referrer_host").
+		Limit(limit).
+		Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	referrers := make([]domain.ReferrerViews, 0, len(rows))
+	for _, row := range rows {
+		referrers = append(referrers, domain.ReferrerViews{Host: row.Host, Views: row.Views})
+	}
+	return referrers, nil
+}
+
+// GetLatencyViews returns the views of each latency bucket since the day.
+func (r *PageViewBunRepository) GetLatencyViews(ctx context.Context, since time.Time) ([]domain.LatencyViews, error) {
+	var rows []struct {
+		Latency string `bun:"latency"`
+		Views   int    `bun:"views"`
+	}
+	err := r.DB.NewSelect().Model((*PageViewBun)(nil)).
+		Column("latency").
+		ColumnExpr("sum(count) AS views").
+		Where("day >= ?", since).
+		Group("latency").
+		Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	latency := make([]domain.LatencyViews, 0, len(rows))
+	for _, row := range rows {
+		latency = append(latency, domain.LatencyViews{Bucket: domain.LatencyBucket(row.Latency), Views: row.Views})
+	}
+	return latency, nil
+}
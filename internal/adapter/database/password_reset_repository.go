@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"spahtmx/internal/domain"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type PasswordResetBunRepository struct {
+	DB *bun.DB
+}
+
+type PasswordResetBun struct {
+	bun.BaseModel `bun:"table:password_resets"`
+
+	Hash      string    `bun:"hash,pk"`
+	UserID    int64     `bun:"user_id,notnull"`
+	CreatedAt time.Time `bun:"created_at,notnull"`
+	ExpiresAt time.Time `bun:"expires_at,notnull"`
+	UsedAt    time.Time `bun:"used_at,nullzero"`
+}
+
+func ToPasswordResetDomain(r PasswordResetBun) domain.PasswordReset {
+	return domain.PasswordReset{
+		Hash:      r.Hash,
+		UserID:    r.UserID,
+		CreatedAt: r.CreatedAt,
+		ExpiresAt: r.ExpiresAt,
+		UsedAt:    r.UsedAt,
+	}
+}
+
+func FromPasswordResetDomain(r domain.PasswordReset) *PasswordResetBun {
+	return &PasswordResetBun{
+		Hash:      r.Hash,
+		UserID:    r.UserID,
+		CreatedAt: r.CreatedAt,
+		ExpiresAt: r.ExpiresAt,
+		UsedAt:    r.UsedAt,
+	}
+}
+
+func (r *PasswordResetBunRepository) CreatePasswordReset(ctx context.Context, reset domain.PasswordReset) error {
+	_, err := r.DB.NewInsert().Model(FromPasswordResetDomain(reset)).Exec(ctx)
+	return err
+}
+
+func (r *PasswordResetBunRepository) GetPasswordReset(ctx context.Context, hash string) (domain.PasswordReset, error) {
+	var reset PasswordResetBun
+	err := r.DB.NewSelect().Model(&reset).Where("hash = ?", hash).Scan(ctx)
+	if err != nil {
+		return domain.PasswordReset{}, translateNotFound(err, domain.ErrInvalidToken)
+	}
+	return ToPasswordResetDomain(reset), nil
+}
+
+// UsePasswordReset marks an unused token as used. It reports
+// ErrInvalidToken when the token was used in the meantime, so that it cannot
+// reset the password twice.
+func (r *PasswordResetBunRepository) UsePasswordReset(ctx context.Context, hash string, at time.Time) error {
+	res, err := r.DB.NewUpdate().Model((*PasswordResetBun)(nil)).
+		Set("used_at = ?", at).
+		Where("hash = ?", hash).
+		Where("used_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res, domain.ErrInvalidToken)
+}
+
+// CountPasswordResets counts the resets requested for a user since the
+// given time.
+func (r *PasswordResetBunRepository) CountPasswordResets(ctx context.Context, userID int64, since time.Time) (int, error) {
+	return r.DB.NewSelect().Model((*PasswordResetBun)(nil)).
+		Where("user_id = ?", userID).
+		Where("created_at >= ?", since).
+		Count(ctx)
+}
+
+func (r *PasswordResetBunRepository) DeleteUserPasswordResets(ctx context.Context, userID int64) error {
+	_, err := r.DB.NewDelete().Model((*PasswordResetBun)(nil)).Where("user_id = ?", userID).Exec(ctx)
+	return err
+}
+
+func (r *PasswordResetBunRepository) DeleteExpiredPasswordResets(ctx context.Context, now time.Time) error {
+	_, err := r.DB.NewDelete().Model((*PasswordResetBun)(nil)).Where("expires_at <= ?", now).Exec(ctx)
+	return err
+}
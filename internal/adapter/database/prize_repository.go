@@ -15,39 +15,147 @@ type PrizeBunRepository struct {
 type PrizeBun struct {
 	bun.BaseModel `bun:"table:prizes"`
 
-	ID                int64         `bun:"id,pk,autoincrement"`
-	Year              string        `bun:"year"`
-	Category          string        `bun:"category"`
-	OverallMotivation string        `bun:"overall_motivation"`
-	Laureates         []LaureateBun `bun:"laureates,rel:has-many,join:id=prize_id"`
+	ID                int64              `bun:"id,pk,autoincrement"`
+	Year              int                `bun:"year,notnull"`
+	Category          string             `bun:"category,notnull"`
+	OverallMotivation string             `bun:"overall_motivation"`
+	Laureates         []PrizeLaureateBun `bun:"laureates,rel:has-many,join:id=prize_id"`
 }
 
+// LaureateBun is a person or organisation, keyed by the Nobel laureate id.
 type LaureateBun struct {
 	bun.BaseModel `bun:"table:laureates"`
 
-	ID         int64  `bun:"id,pk,autoincrement"`
-	Firstname  string `bun:"firstname"`
-	Surname    string `bun:"surname"`
-	Motivation string `bun:"motivation"`
-	Share      string `bun:"share"`
-	PrizeID    int64  `bun:"prize_id"`
+	ID        string `bun:"id,pk"`
+	Kind      string `bun:"kind,notnull,default:'person'"`
+	Firstname string `bun:"firstname"`
+	Surname   string `bun:"surname"`
+}
+
+// PrizeLaureateBun links a laureate to a prize and carries what is specific
+// to that award: the laureate's share and motivation, and their position in
+// the prize's list of laureates.
+type PrizeLaureateBun struct {
+	bun.BaseModel `bun:"table:prize_laureates"`
+
+	PrizeID    int64        `bun:"prize_id,pk"`
+	LaureateID string       `bun:"laureate_id,pk"`
+	Position   int          `bun:"position"`
+	Motivation string       `bun:"motivation"`
+	Share      int          `bun:"share"`
+	Laureate   *LaureateBun `bun:"rel:belongs-to,join:laureate_id=id"`
+}
+
+// withLaureates loads the laureates of the selected prizes, in prize order.
+func withLaureates(q *bun.SelectQuery) *bun.SelectQuery {
+	return q.Relation("Laureates", func(q *bun.SelectQuery) *bun.SelectQuery {
+		return q.Order("position")
+	}).Relation("Laureates.Laureate")
+}
+
+// Full-text documents searched by the criteria query. The same expressions back the GIN
+// indexes created by CreateSearchIndexes so the planner can use them.
+const (
+	prizeMotivationDocument    = "to_tsvector('english', overall_motivation)"
+	laureateMotivationDocument = "to_tsvector('english', motivation)"
+	laureateNameDocument       = "to_tsvector('simple', firstname || ' ' || surname)"
+)
+
+// CreateSearchIndexes creates the GIN indexes used by full-text search.
+func CreateSearchIndexes(ctx context.Context, db *bun.DB) error {
+	indexes := []struct {
+		model    interface{}
+		name     string
+		document string
+	}{
+		{(*PrizeBun)(nil), "prizes_motivation_fts_idx", prizeMotivationDocument},
+		{(*PrizeLaureateBun)(nil), "prize_laureates_motivation_fts_idx", laureateMotivationDocument},
+		{(*LaureateBun)(nil), "laureates_name_fts_idx", laureateNameDocument},
+	}
+
+	for _, idx := range indexes {
+		_, err := db.NewCreateIndex().Model(idx.model).Index(idx.name).IfNotExists().Using("GIN").ColumnExpr(idx.document).Exec(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prizeMigrations bring prize tables created by an earlier version up to
+// date, since CreateTable leaves existing tables alone. Years used to be
+// text, and each laureate row used to belong to a single prize and carry
+// its share and motivation. Those rows have no Nobel laureate id: each one
+// keeps its own laureate, keyed by its old numeric id, until the database
+// is reseeded.
+var prizeMigrations = []string{
+	`DO $$
+	BEGIN
+		IF EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = 'prizes'
+				AND column_name = 'year' AND data_type <> 'integer'
+		) THEN
+			ALTER TABLE prizes ALTER COLUMN year TYPE integer USING year::integer;
+		END IF;
+	END $$`,
+	`ALTER TABLE prizes ALTER COLUMN year SET NOT NULL`,
+	`ALTER TABLE prizes ALTER COLUMN category SET NOT NULL`,
+	`DO $$
+	BEGIN
+		IF EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = 'laureates'
+				AND column_name = 'prize_id'
+		) THEN
+			INSERT INTO prize_laureates (prize_id, laureate_id, position, motivation, share)
+			SELECT prize_id, id::text, row_number() OVER (PARTITION BY prize_id ORDER BY id) - 1,
+				motivation, NULLIF(share, '')::integer
+			FROM laureates;
+			ALTER TABLE laureates DROP COLUMN prize_id, DROP COLUMN motivation, DROP COLUMN share;
+			ALTER TABLE laureates ALTER COLUMN id DROP DEFAULT;
+			ALTER TABLE laureates ALTER COLUMN id TYPE VARCHAR USING id::text;
+			DROP SEQUENCE IF EXISTS laureates_id_seq;
+		END IF;
+	END $$`,
+	`DO $$
+	BEGIN
+		IF NOT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = 'laureates'
+				AND column_name = 'kind'
+		) THEN
+			ALTER TABLE laureates ADD COLUMN kind VARCHAR NOT NULL DEFAULT 'person';
+			UPDATE laureates SET kind = 'organisation' WHERE COALESCE(surname, '') = '';
+		END IF;
+	END $$`,
+}
+
+// MigratePrizes converts prize tables created by an earlier version: integer
+// years, laureates linked to prizes through prize_laureates, and laureate
+// kinds, inferred from the name as on import. It runs after CreateTable,
+// which creates the missing prize_laureates table.
+func MigratePrizes(ctx context.Context, db *bun.DB) error {
+	for _, query := range prizeMigrations {
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func ToPrizeDomain(p PrizeBun) domain.Prize {
 
 	return domain.Prize{
+		ID:                p.ID,
 		Year:              p.Year,
-		Category:          p.Category,
+		Category:          domain.Category(p.Category),
 		OverallMotivation: p.OverallMotivation,
 		Laureates: func() []domain.Laureate {
 			var laureates []domain.Laureate
-			for _, l := range p.Laureates {
-				laureates = append(laureates, domain.Laureate{
-					Firstname:  l.Firstname,
-					Surname:    l.Surname,
-					Motivation: l.Motivation,
-					Share:      l.Share,
-				})
+			for _, pl := range p.Laureates {
+				laureates = append(laureates, ToLaureateDomain(pl))
 			}
 			return laureates
 		}(),
@@ -55,20 +163,52 @@ func ToPrizeDomain(p PrizeBun) domain.Prize {
 
 }
 
+func ToLaureateDomain(pl PrizeLaureateBun) domain.Laureate {
+
+	laureate := domain.Laureate{
+		ID:         pl.LaureateID,
+		Motivation: pl.Motivation,
+		Share:      domain.Share(pl.Share),
+	}
+	if pl.Laureate != nil {
+		laureate.Kind = domain.LaureateKind(pl.Laureate.Kind)
+		laureate.Firstname = pl.Laureate.Firstname
+		laureate.Surname = pl.Laureate.Surname
+	}
+
+	return laureate
+}
+
+// laureateKind defaults a laureate of unknown kind to a person.
+func laureateKind(l domain.Laureate) domain.LaureateKind {
+	if l.Kind == "" {
+		return domain.KindPerson
+	}
+	return l.Kind
+}
+
 func FromPrizeDomain(prize domain.Prize) (*PrizeBun, error) {
 
 	return &PrizeBun{
+		ID:                prize.ID,
 		Year:              prize.Year,
-		Category:          prize.Category,
+		Category:          string(prize.Category),
 		OverallMotivation: prize.OverallMotivation,
-		Laureates: func() []LaureateBun {
-			var laureates []LaureateBun
-			for _, l := range prize.Laureates {
-				laureates = append(laureates, LaureateBun{
-					Firstname:  l.Firstname,
-					Surname:    l.Surname,
+		Laureates: func() []PrizeLaureateBun {
+			var laureates []PrizeLaureateBun
+			for i, l := range prize.Laureates {
+				laureates = append(laureates, PrizeLaureateBun{
+					PrizeID:    prize.ID,
+					LaureateID: l.ID,
+					Position:   i,
 					Motivation: l.Motivation,
-					Share:      l.Share,
+					Share:      int(l.Share),
+					Laureate: &LaureateBun{
+						ID:        l.ID,
+						Kind:      string(laureateKind(l)),
+						Firstname: l.Firstname,
+						Surname:   l.Surname,
+					},
 				})
 			}
 			return laureates
@@ -76,6 +216,8 @@ func FromPrizeDomain(prize domain.Prize) (*PrizeBun, error) {
 	}, nil
 }
 
+// Save inserts the prize and links its laureates. Laureates are upserted on
+// their Nobel id, so a person awarded several prizes is stored only once.
 func (r *PrizeBunRepository) Save(ctx context.Context, prize domain.Prize) error {
 
 	prizeBun, err := FromPrizeDomain(prize)
@@ -93,8 +235,20 @@ func (r *PrizeBunRepository) Save(ctx context.Context, prize domain.Prize) error
 			return nil
 		}
 
+		people := make([]LaureateBun, 0, len(prizeBun.Laureates))
 		for i := range prizeBun.Laureates {
 			prizeBun.Laureates[i].PrizeID = prizeBun.ID
+			people = append(people, *prizeBun.Laureates[i].Laureate)
+		}
+
+		_, err = tx.NewInsert().Model(&people).
+			On("CONFLICT (id) DO UPDATE").
+			Set("kind = EXCLUDED.kind").
+			Set("firstname = EXCLUDED.firstname").
+			Set("surname = EXCLUDED.surname").
+			Exec(ctx)
+		if err != nil {
+			return err
 		}
 
 		_, err = tx.NewInsert().Model(&prizeBun.Laureates).Exec(ctx)
@@ -109,7 +263,7 @@ func (r *PrizeBunRepository) Save(ctx context.Context, prize domain.Prize) error
 func (r *PrizeBunRepository) FindAll(ctx context.Context) ([]domain.Prize, error) {
 
 	var prizes []PrizeBun
-	err := r.DB.NewSelect().Model(&prizes).Relation("Laureates").Scan(ctx)
+	err := r.DB.NewSelect().Model(&prizes).Apply(withLaureates).Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -125,23 +279,81 @@ func (r *PrizeBunRepository) FindAll(ctx context.Context) ([]domain.Prize, error
 func (r *PrizeBunRepository) FindByID(ctx context.Context, id int64) (*domain.Prize, error) {
 
 	var prize PrizeBun
-	err := r.DB.NewSelect().Model(&prize).Relation("Laureates").Where("id = ?", id).Scan(ctx)
+	err := r.DB.NewSelect().Model(&prize).Apply(withLaureates).Where("id = ?", id).Scan(ctx)
 	if err != nil {
-		return nil, err
+		return nil, translateNotFound(err, domain.PrizeNotFound)
 	}
 
 	domainPrize := ToPrizeDomain(prize)
 	return &domainPrize, nil
 }
 
+// FindNeighbours returns up to n prizes of the category awarded before the
+// given year and up to n awarded after it, ordered by year.
+func (r *PrizeBunRepository) FindNeighbours(ctx context.Context, category domain.Category, year int, n int) ([]domain.Prize, error) {
+
+	var before []PrizeBun
+	err := r.DB.NewSelect().Model(&before).Apply(withLaureates).Where("category = ? AND year < ?", category, year).Order("year DESC").Limit(n).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var after []PrizeBun
+	err = r.DB.NewSelect().Model(&after).Apply(withLaureates).Where("category = ? AND year > ?", category, year).Order("year ASC").Limit(n).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var domainPrizes []domain.Prize
+	for i := len(before) - 1; i >= 0; i-- {
+		domainPrizes = append(domainPrizes, ToPrizeDomain(before[i]))
+	}
+	for _, p := range after {
+		domainPrizes = append(domainPrizes, ToPrizeDomain(p))
+	}
+
+	return domainPrizes, nil
+}
+
+// FindLaureateByID loads a laureate and every prize they were awarded,
+// ordered by year, with all co-laureates of each prize.
+func (r *PrizeBunRepository) FindLaureateByID(ctx context.Context, id string) (*domain.LaureateProfile, error) {
+
+	var laureate LaureateBun
+	err := r.DB.NewSelect().Model(&laureate).Where("id = ?", id).Scan(ctx)
+	if err != nil {
+		return nil, translateNotFound(err, domain.ErrLaureateNotFound)
+	}
+
+	awarded := r.DB.NewSelect().Model((*PrizeLaureateBun)(nil)).Column("prize_id").Where("laureate_id = ?", id)
+
+	var prizes []PrizeBun
+	err = r.DB.NewSelect().Model(&prizes).Apply(withLaureates).Where("id IN (?)", awarded).Order("year", "category").Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := domain.LaureateProfile{
+		ID:        laureate.ID,
+		Kind:      domain.LaureateKind(laureate.Kind),
+		Firstname: laureate.Firstname,
+		Surname:   laureate.Surname,
+	}
+	for _, p := range prizes {
+		profile.Prizes = append(profile.Prizes, ToPrizeDomain(p))
+	}
+
+	return &profile, nil
+}
+
 func (r *PrizeBunRepository) DeleteByID(ctx context.Context, id int64) error {
 
-	_, err := r.DB.NewDelete().Model((*PrizeBun)(nil)).Where("id = ?", id).Exec(ctx)
+	res, err := r.DB.NewDelete().Model((*PrizeBun)(nil)).Where("id = ?", id).Exec(ctx)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return checkAffected(res, domain.PrizeNotFound)
 }
 
 func (r *PrizeBunRepository) Update(ctx context.Context, prize domain.Prize) error {
@@ -151,66 +363,158 @@ func (r *PrizeBunRepository) Update(ctx context.Context, prize domain.Prize) err
 		return err
 	}
 
-	_, err = r.DB.NewUpdate().Model(prizeBun).Where("id = ?", prize.ID).Exec(ctx)
+	res, err := r.DB.NewUpdate().Model(prizeBun).WherePK().Exec(ctx)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return checkAffected(res, domain.PrizeNotFound)
 }
 
-func (r *PrizeBunRepository) FindByYear(ctx context.Context, year string) ([]domain.Prize, error) {
+// FindPage returns up to limit prizes matching the criteria that follow the
+// cursor in the criteria's order. One extra row is fetched to know whether a
+// next page exists.
+func (r *PrizeBunRepository) FindPage(ctx context.Context, criteria domain.PrizeCriteria, after domain.PrizeCursor, limit int) (*domain.PrizePage, error) {
 
 	var prizes []PrizeBun
-	err := r.DB.NewSelect().Model(&prizes).Relation("Laureates").Where("year = ?", year).Scan(ctx)
+	q := r.DB.NewSelect().Model(&prizes).Apply(withLaureates).Apply(func(q *bun.SelectQuery) *bun.SelectQuery {
+		return applyCriteria(q, criteria)
+	})
+
+	q, err := applySort(q, criteria.Sort, after)
 	if err != nil {
 		return nil, err
 	}
 
-	var domainPrizes []domain.Prize
+	err = q.Limit(limit + 1).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	more := len(prizes) > limit
+	if more {
+		prizes = prizes[:limit]
+	}
+
+	page := &domain.PrizePage{}
 	for _, p := range prizes {
-		domainPrizes = append(domainPrizes, ToPrizeDomain(p))
+		page.Prizes = append(page.Prizes, ToPrizeDomain(p))
+	}
+	if more {
+		last := page.Prizes[len(page.Prizes)-1]
+		page.Next = &domain.PrizeCursor{Year: last.Year, Category: last.Category, Key: last.SortKey(criteria.Sort)}
 	}
 
-	return domainPrizes, nil
+	return page, nil
 }
 
-func (r *PrizeBunRepository) FindByCategory(ctx context.Context, category string) ([]domain.Prize, error) {
-
-	var prizes []PrizeBun
-	err := r.DB.NewSelect().Model(&prizes).Relation("Laureates").Where("category = ?", category).Scan(ctx)
-	if err != nil {
-		return nil, err
+// applyCriteria translates the criteria filters into WHERE clauses. Each
+// search term must match as a word prefix in the overall motivation, a
+// laureate motivation or a laureate name.
+func applyCriteria(q *bun.SelectQuery, criteria domain.PrizeCriteria) *bun.SelectQuery {
+	for _, term := range criteria.Terms() {
+		tsquery := term + ":*"
+		// Un mot vide comme « the » ou « of » donne une requête anglaise vide
+		// qui ne correspond à rien : le terme est alors ignoré
+		q = q.Where("(numnode(to_tsquery('english', ?0)) = 0"+
+			" OR to_tsvector('english', ?TableAlias.overall_motivation) @@ to_tsquery('english', ?0)"+
+			" OR EXISTS (SELECT 1 FROM prize_laureates AS pl JOIN laureates AS l ON l.id = pl.laureate_id"+
+			" WHERE pl.prize_id = ?TableAlias.id"+
+			" AND (to_tsvector('english', pl.motivation) @@ to_tsquery('english', ?0)"+
+			" OR to_tsvector('simple', l.firstname || ' ' || l.surname) @@ to_tsquery('simple', ?0))))", tsquery)
 	}
-
-	var domainPrizes []domain.Prize
-	for _, p := range prizes {
-		domainPrizes = append(domainPrizes, ToPrizeDomain(p))
+	if len(criteria.Categories) > 0 {
+		q = q.Where("category IN (?)", bun.In(criteria.Categories))
+	}
+	if criteria.YearFrom != 0 {
+		q = q.Where("year >= ?", criteria.YearFrom)
+	}
+	if criteria.YearTo != 0 {
+		q = q.Where("year <= ?", criteria.YearTo)
+	}
+	if criteria.HasOverallMotivation {
+		q = q.Where("overall_motivation <> ''")
+	}
+	if criteria.MinLaureates > 0 {
+		q = q.Where(laureateCountExpr+" >= ?", criteria.MinLaureates)
+	}
+	switch criteria.Sharing {
+	case domain.SharingSolo:
+		q = q.Where(laureateCountExpr + " = 1")
+	case domain.SharingShared:
+		q = q.Where(laureateCountExpr + " > 1")
+	}
+	if criteria.LaureateKind != "" {
+		// Tous les lauréats doivent être de ce type, sans quoi un prix partagé
+		// entre une personne et une organisation passerait les deux filtres
+		q = q.Where("EXISTS (SELECT 1 FROM prize_laureates AS plk WHERE plk.prize_id = ?TableAlias.id)").
+			Where("NOT EXISTS (SELECT 1 FROM prize_laureates AS plk JOIN laureates AS lk ON lk.id = plk.laureate_id"+
+				" WHERE plk.prize_id = ?TableAlias.id AND lk.kind <> ?)", criteria.LaureateKind)
 	}
 
-	return domainPrizes, nil
+	return q
 }
 
-func (r *PrizeBunRepository) FindByCategoryAndYear(ctx context.Context, category string, year string) ([]domain.Prize, error) {
+// Sort keys computed per prize, matching domain.Prize.SortKey.
+const (
+	laureateCountExpr = "(SELECT count(*) FROM prize_laureates AS plc WHERE plc.prize_id = ?TableAlias.id)"
+	firstSurnameExpr  = "(SELECT COALESCE(NULLIF(l.surname, ''), l.firstname) FROM prize_laureates AS pls" +
+		" JOIN laureates AS l ON l.id = pls.laureate_id WHERE pls.prize_id = ?TableAlias.id ORDER BY pls.position LIMIT 1)"
+)
 
-	var prizes []PrizeBun
-	err := r.DB.NewSelect().Model(&prizes).Relation("Laureates").Where("category = ? AND year = ?", category, year).Scan(ctx)
-	if err != nil {
-		return nil, err
-	}
+// afterYearDesc is the keyset condition of the default order (year
+// descending, then category), also used to break ties in the other orders.
+const afterYearDesc = "(year < ? OR (year = ? AND category > ?))"
 
-	var domainPrizes []domain.Prize
-	for _, p := range prizes {
-		domainPrizes = append(domainPrizes, ToPrizeDomain(p))
+// applySort orders the query and, when a cursor is given, restricts it to
+// the rows that follow the cursor in that order.
+func applySort(q *bun.SelectQuery, sort domain.PrizeSort, after domain.PrizeCursor) (*bun.SelectQuery, error) {
+	y, c := after.Year, after.Category
+
+	switch sort {
+	case domain.SortYearAsc:
+		q = q.Order("year ASC", "category ASC")
+		if !after.IsZero() {
+			q = q.Where("(year > ? OR (year = ? AND category > ?))", y, y, c)
+		}
+	case domain.SortCategory:
+		q = q.Order("category ASC", "year DESC")
+		if !after.IsZero() {
+			q = q.Where("(category > ? OR (category = ? AND year < ?))", c, c, y)
+		}
+	case domain.SortLaureates:
+		q = q.OrderExpr(laureateCountExpr+" DESC").Order("year DESC", "category ASC")
+		if !after.IsZero() {
+			count, err := strconv.Atoi(after.Key)
+			if err != nil {
+				return nil, domain.ErrInvalidInput
+			}
+			q = q.Where("("+laureateCountExpr+" < ? OR ("+laureateCountExpr+" = ? AND "+afterYearDesc+"))", count, count, y, y, c)
+		}
+	case domain.SortSurname:
+		// Prizes without laureates have no surname and come last
+		q = q.OrderExpr(firstSurnameExpr+" IS NULL").OrderExpr(firstSurnameExpr+" ASC").Order("year DESC", "category ASC")
+		if !after.IsZero() {
+			if after.Key == "" {
+				q = q.Where("("+firstSurnameExpr+" IS NULL AND "+afterYearDesc+")", y, y, c)
+			} else {
+				q = q.Where("("+firstSurnameExpr+" IS NULL OR "+firstSurnameExpr+" > ? OR ("+firstSurnameExpr+" = ? AND "+afterYearDesc+"))", after.Key, after.Key, y, y, c)
+			}
+		}
+	default:
+		q = q.Order("year DESC", "category ASC")
+		if !after.IsZero() {
+			q = q.Where(afterYearDesc, y, y, c)
+		}
 	}
 
-	return domainPrizes, nil
+	return q, nil
 }
 
-func (r *PrizeBunRepository) GetCategories(ctx context.Context) ([]string, error) {
+func (r *PrizeBunRepository) GetCategories(ctx context.Context) ([]domain.Category, error) {
 
-	var categories []string
-	err := r.DB.NewSelect().Model((*PrizeBun)(nil)).Column("category").Distinct().Scan(ctx, &categories)
+	var categories []domain.Category
+	err := r.DB.NewSelect().Model((*PrizeBun)(nil)).Column("category").Distinct().Order("category ASC").Scan(ctx, &categories)
 	if err != nil {
 		return nil, err
 	}
@@ -218,10 +522,10 @@ func (r *PrizeBunRepository) GetCategories(ctx context.Context) ([]string, error
 	return categories, nil
 }
 
-func (r *PrizeBunRepository) GetYears(ctx context.Context) ([]string, error) {
+func (r *PrizeBunRepository) GetYears(ctx context.Context) ([]int, error) {
 
-	var years []string
-	err := r.DB.NewSelect().Model((*PrizeBun)(nil)).Column("year").Distinct().Scan(ctx, &years)
+	var years []int
+	err := r.DB.NewSelect().Model((*PrizeBun)(nil)).Column("year").Distinct().Order("year DESC").Scan(ctx, &years)
 	if err != nil {
 		return nil, err
 	}
@@ -229,12 +533,8 @@ func (r *PrizeBunRepository) GetYears(ctx context.Context) ([]string, error) {
 	return years, nil
 }
 
-func (r *PrizeBunRepository) GetPrizes(ctx context.Context) ([]domain.Prize, error) {
-	return r.FindAll(ctx)
-}
-
 func (r *PrizeBunRepository) GetPrize(ctx context.Context, id string) (domain.Prize, error) {
-	prizeID, err := strconv.ParseInt(id, 10, 64)
+	prizeID, err := parseID(id)
 	if err != nil {
 		return domain.Prize{}, err
 	}
@@ -247,14 +547,24 @@ func (r *PrizeBunRepository) GetPrize(ctx context.Context, id string) (domain.Pr
 	return *prize, nil
 }
 
-func (r *PrizeBunRepository) GetPrizesByYear(ctx context.Context, year string) ([]domain.Prize, error) {
-	return r.FindByYear(ctx, year)
+func (r *PrizeBunRepository) GetNeighbourPrizes(ctx context.Context, category domain.Category, year int, n int) ([]domain.Prize, error) {
+	return r.FindNeighbours(ctx, category, year, n)
 }
 
-func (r *PrizeBunRepository) GetPrizesByCategory(ctx context.Context, category string) ([]domain.Prize, error) {
-	return r.FindByCategory(ctx, category)
+func (r *PrizeBunRepository) GetPrizePage(ctx context.Context, criteria domain.PrizeCriteria, after domain.PrizeCursor, limit int) (domain.PrizePage, error) {
+	page, err := r.FindPage(ctx, criteria, after, limit)
+	if err != nil {
+		return domain.PrizePage{}, err
+	}
+
+	return *page, nil
 }
 
-func (r *PrizeBunRepository) GetPrizesByCategoryAndYear(ctx context.Context, category string, year string) ([]domain.Prize, error) {
-	return r.FindByCategoryAndYear(ctx, category, year)
+func (r *PrizeBunRepository) GetLaureate(ctx context.Context, id string) (domain.LaureateProfile, error) {
+	profile, err := r.FindLaureateByID(ctx, id)
+	if err != nil {
+		return domain.LaureateProfile{}, err
+	}
+
+	return *profile, nil
 }
@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"spahtmx/internal/domain"
+
+	"github.com/uptrace/bun"
+)
+
+const decadeExpr = "(?TableAlias.year / 10) * 10"
+
+// applyStatsFilter restricts prizes, aliased as alias, to the filter's
+// category and decade.
+func applyStatsFilter(q *bun.SelectQuery, filter domain.StatsFilter, alias string) *bun.SelectQuery {
+	if filter.Category != "" {
+		q = q.Where("?.category = ?", bun.Ident(alias), filter.Category)
+	}
+	if filter.Decade != 0 {
+		q = q.Where("?.year BETWEEN ? AND ?", bun.Ident(alias), filter.Decade, filter.Decade+9)
+	}
+	return q
+}
+
+// FindPrizesPerCategoryDecade counts the prizes of each category per decade,
+// ordered by decade then category.
+func (r *PrizeBunRepository) FindPrizesPerCategoryDecade(ctx context.Context, filter domain.StatsFilter) ([]domain.CategoryDecadeCount, error) {
+
+	var rows []struct {
+		Category string `bun:"category"`
+		Decade   int    `bun:"decade"`
+		Count    int    `bun:"count"`
+	}
+	q := r.DB.NewSelect().Model((*PrizeBun)(nil)).
+		Column("category").
+		ColumnExpr(decadeExpr+" AS decade").
+		ColumnExpr("count(*) AS count").
+		Group("category", "decade").
+		Order("decade", "category")
+	err := applyStatsFilter(q, filter, "prize_bun").Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]domain.CategoryDecadeCount, 0, len(rows))
+	for _, row := range rows {
+		counts = append(counts, domain.CategoryDecadeCount{
+			Category: domain.Category(row.Category),
+			Decade:   row.Decade,
+			Count:    row.Count,
+		})
+	}
+	return counts, nil
+}
+
+// FindSharingPerDecade counts solo and shared prizes per decade.
+func (r *PrizeBunRepository) FindSharingPerDecade(ctx context.Context, filter domain.StatsFilter) ([]domain.SharingCount, error) {
+
+	var rows []struct {
+		Decade int `bun:"decade"`
+		Solo   int `bun:"solo"`
+		Shared int `bun:"shared"`
+	}
+	q := r.DB.NewSelect().Model((*PrizeBun)(nil)).
+		ColumnExpr(decadeExpr + " AS decade").
+		ColumnExpr("count(*) FILTER (WHERE " + laureateCountExpr + " = 1) AS solo").
+		ColumnExpr("count(*) FILTER (WHERE " + laureateCountExpr + " > 1) AS shared").
+		Group("decade").
+		Order("decade")
+	err := applyStatsFilter(q, filter, "prize_bun").Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]domain.SharingCount, 0, len(rows))
+	for _, row := range rows {
+		counts = append(counts, domain.SharingCount{Decade: row.Decade, Solo: row.Solo, Shared: row.Shared})
+	}
+	return counts, nil
+}
+
+// FindMultipleLaureates returns the laureates awarded more than one of the
+// selected prizes, most awarded first.
+func (r *PrizeBunRepository) FindMultipleLaureates(ctx context.Context, filter domain.StatsFilter) ([]domain.LaureateCount, error) {
+
+	var rows []struct {
+		LaureateBun
+		Prizes int `bun:"prizes"`
+	}
+	q := r.DB.NewSelect().Model((*LaureateBun)(nil)).
+		ColumnExpr("?TableAlias.*").
+		ColumnExpr("count(*) AS prizes").
+		Join("JOIN prize_laureates AS pl ON pl.laureate_id = ?TableAlias.id").
+		Join("JOIN prizes AS p ON p.id = pl.prize_id").
+		GroupExpr("?TableAlias.id").
+		Having("count(*) > 1").
+		OrderExpr("prizes DESC").
+		OrderExpr("COALESCE(NULLIF(?TableAlias.surname, ''), ?TableAlias.firstname)")
+	err := applyStatsFilter(q, filter, "p").Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]domain.LaureateCount, 0, len(rows))
+	for _, row := range rows {
+		counts = append(counts, domain.LaureateCount{
+			Laureate: domain.Laureate{
+				ID:        row.ID,
+				Kind:      domain.LaureateKind(row.Kind),
+				Firstname: row.Firstname,
+				Surname:   row.Surname,
+			},
+			Prizes: row.Prizes,
+		})
+	}
+	return counts, nil
+}
+
+// FindAverageLaureates totals prizes and laureates per category.
+func (r *PrizeBunRepository) FindAverageLaureates(ctx context.Context, filter domain.StatsFilter) ([]domain.CategoryAverage, error) {
+
+	var rows []struct {
+		Category  string `bun:"category"`
+		Prizes    int    `bun:"prizes"`
+		Laureates int    `bun:"laureates"`
+	}
+	q := r.DB.NewSelect().Model((*PrizeBun)(nil)).
+		Column("category").
+		ColumnExpr("count(*) AS prizes").
+		ColumnExpr("coalesce(sum(" + laureateCountExpr + "), 0) AS laureates").
+		Group("category").
+		Order("category")
+	err := applyStatsFilter(q, filter, "prize_bun").Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	averages := make([]domain.CategoryAverage, 0, len(rows))
+	for _, row := range rows {
+		averages = append(averages, domain.CategoryAverage{
+			Category:  domain.Category(row.Category),
+			Prizes:    row.Prizes,
+			Laureates: row.Laureates,
+		})
+	}
+	return averages, nil
+}
+
+func (r *PrizeBunRepository) GetPrizesPerCategoryDecade(ctx context.Context, filter domain.StatsFilter) ([]domain.CategoryDecadeCount, error) {
+	return r.FindPrizesPerCategoryDecade(ctx, filter)
+}
+
+func (r *PrizeBunRepository) GetSharingPerDecade(ctx context.Context, filter domain.StatsFilter) ([]domain.SharingCount, error) {
+	return r.FindSharingPerDecade(ctx, filter)
+}
+
+func (r *PrizeBunRepository) GetMultipleLaureates(ctx context.Context, filter domain.StatsFilter) ([]domain.LaureateCount, error) {
+	return r.FindMultipleLaureates(ctx, filter)
+}
+
+func (r *PrizeBunRepository) GetAverageLaureates(ctx context.Context, filter domain.StatsFilter) ([]domain.CategoryAverage, error) {
+	return r.FindAverageLaureates(ctx, filter)
+}
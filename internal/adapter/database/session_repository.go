@@ -0,0 +1,211 @@
+package database
+
+import (
+	"context"
+	"spahtmx/internal/domain"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type SessionBunRepository struct {
+	DB *bun.DB
+}
+
+type SessionBun struct {
+	bun.BaseModel `bun:"table:sessions"`
+
+	ID         string    `bun:"id,pk"`
+	UserID     int64     `bun:"user_id,notnull"`
+	UserAgent  string    `bun:"user_agent"`
+	CreatedAt  time.Time `bun:"created_at,notnull"`
+	LastSeenAt time.Time `bun:"last_seen_at,notnull"`
+	ExpiresAt  time.Time `bun:"expires_at,notnull"`
+	User       *UserBun  `bun:"rel:belongs-to,join:user_id=id"`
+}
+
+type RefreshTokenBun struct {
+	bun.BaseModel `bun:"table:refresh_tokens"`
+
+	Hash      string    `bun:"hash,pk"`
+	SessionID string    `bun:"session_id,notnull"`
+	CreatedAt time.Time `bun:"created_at,notnull"`
+	ExpiresAt time.Time `bun:"expires_at,notnull"`
+	UsedAt    time.Time `bun:"used_at,nullzero"`
+}
+
+func ToSessionDomain(s SessionBun) domain.Session {
+	session := domain.Session{
+		ID:         s.ID,
+		UserID:     s.UserID,
+		UserAgent:  s.UserAgent,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		ExpiresAt:  s.ExpiresAt,
+	}
+	if s.User != nil {
+		session.Username = s.User.Username
+	}
+	return session
+}
+
+func FromSessionDomain(s domain.Session) *SessionBun {
+	return &SessionBun{
+		ID:         s.ID,
+		UserID:     s.UserID,
+		UserAgent:  s.UserAgent,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		ExpiresAt:  s.ExpiresAt,
+	}
+}
+
+func ToRefreshTokenDomain(t RefreshTokenBun) domain.RefreshToken {
+	return domain.RefreshToken{
+		Hash:      t.Hash,
+		SessionID: t.SessionID,
+		CreatedAt: t.CreatedAt,
+		ExpiresAt: t.ExpiresAt,
+		UsedAt:    t.UsedAt,
+	}
+}
+
+func FromRefreshTokenDomain(t domain.RefreshToken) *RefreshTokenBun {
+	return &RefreshTokenBun{
+		Hash:      t.Hash,
+		SessionID: t.SessionID,
+		CreatedAt: t.CreatedAt,
+		ExpiresAt: t.ExpiresAt,
+		UsedAt:    t.UsedAt,
+	}
+}
+
+func (r *SessionBunRepository) CreateSession(ctx context.Context, session domain.Session) error {
+	_, err := r.DB.NewInsert().Model(FromSessionDomain(session)).Exec(ctx)
+	return err
+}
+
+func (r *SessionBunRepository) GetSession(ctx context.Context, id string) (domain.Session, error) {
+	var session SessionBun
+	err := r.DB.NewSelect().Model(&session).Where("id = ?", id).Scan(ctx)
+	if err != nil {
+		return domain.Session{}, translateNotFound(err, domain.ErrSessionNotFound)
+	}
+	return ToSessionDomain(session), nil
+}
+
+func (r *SessionBunRepository) TouchSession(ctx context.Context, id string, at time.Time) error {
+	res, err := r.DB.NewUpdate().Model((*SessionBun)(nil)).Set("last_seen_at = ?", at).Where("id = ?", id).Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res, domain.ErrSessionNotFound)
+}
+
+// RenewSession records a use of the session and pushes back its expiry.
+func (r *SessionBunRepository) RenewSession(ctx context.Context, id string, at, expiresAt time.Time) error {
+	res, err := r.DB.NewUpdate().Model((*SessionBun)(nil)).
+		Set("last_seen_at = ?", at).
+		Set("expires_at = ?", expiresAt).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res, domain.ErrSessionNotFound)
+}
+
+// GetUserSessions returns the unexpired sessions of a user, most recently
+// used first.
+func (r *SessionBunRepository) GetUserSessions(ctx context.Context, userID int64, now time.Time) ([]domain.Session, error) {
+	var sessions []SessionBun
+	err := r.DB.NewSelect().Model(&sessions).
+		Relation("User").
+		Where("?TableAlias.user_id = ?", userID).
+		Where("?TableAlias.expires_at > ?", now).
+		Order("last_seen_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toSessionsDomain(sessions), nil
+}
+
+// GetActiveSessions returns the unexpired sessions of every user, most
+// recently used first.
+func (r *SessionBunRepository) GetActiveSessions(ctx context.Context, now time.Time) ([]domain.Session, error) {
+	var sessions []SessionBun
+	err := r.DB.NewSelect().Model(&sessions).
+		Relation("User").
+		Where("?TableAlias.expires_at > ?", now).
+		Order("last_seen_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toSessionsDomain(sessions), nil
+}
+
+func toSessionsDomain(sessions []SessionBun) []domain.Session {
+	domainSessions := make([]domain.Session, 0, len(sessions))
+	for _, s := range sessions {
+		domainSessions = append(domainSessions, ToSessionDomain(s))
+	}
+	return domainSessions
+}
+
+func (r *SessionBunRepository) DeleteSession(ctx context.Context, id string) error {
+	res, err := r.DB.NewDelete().Model((*SessionBun)(nil)).Where("id = ?", id).Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res, domain.ErrSessionNotFound)
+}
+
+func (r *SessionBunRepository) DeleteUserSessions(ctx context.Context, userID int64) error {
+	_, err := r.DB.NewDelete().Model((*SessionBun)(nil)).Where("user_id = ?", userID).Exec(ctx)
+	return err
+}
+
+// DeleteExpiredSessions deletes expired sessions along with the refresh
+// tokens that expired or whose session no longer exists.
+func (r *SessionBunRepository) DeleteExpiredSessions(ctx context.Context, now time.Time) error {
+	_, err := r.DB.NewDelete().Model((*SessionBun)(nil)).Where("expires_at <= ?", now).Exec(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB.NewDelete().Model((*RefreshTokenBun)(nil)).
+		Where("expires_at <= ?", now).
+		WhereOr("session_id NOT IN (?)", r.DB.NewSelect().Model((*SessionBun)(nil)).Column("id")).
+		Exec(ctx)
+	return err
+}
+
+func (r *SessionBunRepository) CreateRefreshToken(ctx context.Context, token domain.RefreshToken) error {
+	_, err := r.DB.NewInsert().Model(FromRefreshTokenDomain(token)).Exec(ctx)
+	return err
+}
+
+func (r *SessionBunRepository) GetRefreshToken(ctx context.Context, hash string) (domain.RefreshToken, error) {
+	var token RefreshTokenBun
+	err := r.DB.NewSelect().Model(&token).Where("hash = ?", hash).Scan(ctx)
+	if err != nil {
+		return domain.RefreshToken{}, translateNotFound(err, domain.ErrRefreshNotFound)
+	}
+	return ToRefreshTokenDomain(token), nil
+}
+
+// UseRefreshToken marks an unused token as exchanged. It reports
+// ErrRefreshUsed when the token was used in the meantime, so that two
+// concurrent requests cannot both rotate it.
+func (r *SessionBunRepository) UseRefreshToken(ctx context.Context, hash string, at time.Time) error {
+	res, err := r.DB.NewUpdate().Model((*RefreshTokenBun)(nil)).
+		Set("used_at = ?", at).
+		Where("hash = ?", hash).
+		Where("used_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res, domain.ErrRefreshUsed)
+}
@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"spahtmx/internal/domain"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type TwoFactorBunRepository struct {
+	DB *bun.DB
+}
+
+type TwoFactorBun struct {
+	bun.BaseModel `bun:"table:two_factors"`
+
+	UserID          int64     `bun:"user_id,pk"`
+	EncryptedSecret []byte    `bun:"encrypted_secret,notnull"`
+	CreatedAt       time.Time `bun:"created_at,notnull"`
+	ConfirmedAt     time.Time `bun:"confirmed_at,nullzero"`
+	LastUsedStep    int64     `bun:"last_used_step,notnull"`
+}
+
+type RecoveryCodeBun struct {
+	bun.BaseModel `bun:"table:recovery_codes"`
+
+	UserID int64     `bun:"user_id,pk"`
+	Hash   string    `bun:"hash,pk"`
+	UsedAt time.Time `bun:"used_at,nullzero"`
+}
+
+type RolePolicyBun struct {
+	bun.BaseModel `bun:"table:role_policies"`
+
+	Role             string `bun:"role,pk"`
+	RequireTwoFactor bool   `bun:"require_two_factor,notnull"`
+}
+
+func ToTwoFactorDomain(t TwoFactorBun) domain.TwoFactor {
+	return domain.TwoFactor{
+		UserID:          t.UserID,
+		EncryptedSecret: t.EncryptedSecret,
+		CreatedAt:       t.CreatedAt,
+		ConfirmedAt:     t.ConfirmedAt,
+		LastUsedStep:    t.LastUsedStep,
+	}
+}
+
+func FromTwoFactorDomain(t domain.TwoFactor) *TwoFactorBun {
+	return &TwoFactorBun{
+		UserID:          t.UserID,
+		EncryptedSecret: t.EncryptedSecret,
+		CreatedAt:       t.CreatedAt,
+		ConfirmedAt:     t.ConfirmedAt,
+		LastUsedStep:    t.LastUsedStep,
+	}
+}
+
+func (r *TwoFactorBunRepository) GetTwoFactor(ctx context.Context, userID int64) (domain.TwoFactor, error) {
+	var twoFactor TwoFactorBun
+	err := r.DB.NewSelect().Model(&twoFactor).Where("user_id = ?", userID).Scan(ctx)
+	if err != nil {
+		return domain.TwoFactor{}, translateNotFound(err, domain.ErrTwoFactorMissing)
+	}
+	return ToTwoFactorDomain(twoFactor), nil
+}
+
+// SaveTwoFactor creates or replaces the enrolment of a user.
+func (r *TwoFactorBunRepository) SaveTwoFactor(ctx context.Context, twoFactor domain.TwoFactor) error {
+	_, err := r.DB.NewInsert().Model(FromTwoFactorDomain(twoFactor)).
+		On("CONFLICT (user_id) DO UPDATE").
+		Set("encrypted_secret = EXCLUDED.encrypted_secret").
+		Set("created_at = EXCLUDED.created_at").
+		Set("confirmed_at = EXCLUDED.confirmed_at").
+		Set("last_used_step = EXCLUDED.last_used_step").
+		Exec(ctx)
+	return err
+}
+
+// UseTOTPStep records the time step of an accepted code. It reports
+// ErrInvalidCode when a code of that step or a later one was already
+// accepted, so that a code cannot be replayed, even concurrently.
+func (r *TwoFactorBunRepository) UseTOTPStep(ctx context.Context, userID int64, step int64) error {
+	res, err := r.DB.NewUpdate().Model((*TwoFactorBun)(nil)).
+		Set("last_used_step = ?", step).
+		Where("user_id = ?", userID).
+		Where("last_used_step < ?", step).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res, domain.ErrInvalidCode)
+}
+
+// DeleteTwoFactor removes the enrolment of a user and its recovery codes.
+func (r *TwoFactorBunRepository) DeleteTwoFactor(ctx context.Context, userID int64) error {
+	return r.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewDelete().Model((*RecoveryCodeBun)(nil)).Where("user_id = ?", userID).Exec(ctx); err != nil {
+			return err
+		}
+		_, err := tx.NewDelete().Model((*TwoFactorBun)(nil)).Where("user_id = ?", userID).Exec(ctx)
+		return err
+	})
+}
+
+// ReplaceRecoveryCodes drops the recovery codes of a user, used or not, and
+// stores the new ones.
+func (r *TwoFactorBunRepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, hashes []string) error {
+	codes := make([]RecoveryCodeBun, 0, len(hashes))
+	for _, hash := range hashes {
+		codes = append(codes, RecoveryCodeBun{UserID: userID, Hash: hash})
+	}
+	return r.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewDelete().Model((*RecoveryCodeBun)(nil)).Where("user_id = ?", userID).Exec(ctx); err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		_, err := tx.NewInsert().Model(&codes).Exec(ctx)
+		return err
+	})
+}
+
+// UseRecoveryCode marks an unused recovery code as used. It reports
+// ErrInvalidCode when the user has no such unused code.
+func (r *TwoFactorBunRepository) UseRecoveryCode(ctx context.Context, userID int64, hash string, at time.Time) error {
+	res, err := r.DB.NewUpdate().Model((*RecoveryCodeBun)(nil)).
+		Set("used_at = ?", at).
+		Where("user_id = ?", userID).
+		Where("hash = ?", hash).
+		Where("used_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res, domain.ErrInvalidCode)
+}
+
+// CountRecoveryCodes counts the unused recovery codes of a user.
+func (r *TwoFactorBunRepository) CountRecoveryCodes(ctx context.Context, userID int64) (int, error) {
+	return r.DB.NewSelect().Model((*RecoveryCodeBun)(nil)).
+		Where("user_id = ?", userID).
+		Where("used_at IS NULL").
+		Count(ctx)
+}
+
+func (r *TwoFactorBunRepository) GetRolePolicies(ctx context.Context) ([]domain.RolePolicy, error) {
+	var policies []RolePolicyBun
+	if err := r.DB.NewSelect().Model(&policies).Scan(ctx); err != nil {
+		return nil, err
+	}
+	domainPolicies := make([]domain.RolePolicy, 0, len(policies))
+	for _, p := range policies {
+		domainPolicies = append(domainPolicies, domain.RolePolicy{
+			Role:             domain.Role(p.Role),
+			RequireTwoFactor: p.RequireTwoFactor,
+		})
+	}
+	return domainPolicies, nil
+}
+
+func (r *TwoFactorBunRepository) SaveRolePolicy(ctx context.Context, policy domain.RolePolicy) error {
+	_, err := r.DB.NewInsert().Model(&RolePolicyBun{
+		Role:             string(policy.Role),
+		RequireTwoFactor: policy.RequireTwoFactor,
+	}).
+		On("CONFLICT (role) DO UPDATE").
+		Set("require_two_factor = EXCLUDED.require_two_factor").
+		Exec(ctx)
+	return err
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"spahtmx/internal/domain"
+	"time"
 
 	"github.com/uptrace/bun"
 )
@@ -12,24 +13,68 @@ type UserBunRepository struct {
 }
 
 type UserBun struct {
-
 	bun.BaseModel `bun:"table:users"`
 
-	ID       int64		 `bun:"id,pk,autoincrement"`
-	Username string       
-	Password string       
-	Email    string       
-	Status   bool         
+	ID           int64  `bun:"id,pk,autoincrement"`
+	Username     string `bun:"username,unique"`
+	Password     string
+	Email        string    `bun:"email,unique"`
+	Status       string    `bun:"status,notnull,default:'active'"`
+	StatusReason string    `bun:"status_reason"`
+	LockedUntil  time.Time `bun:"locked_until,nullzero"`
+	Role         string    `bun:"role,notnull,default:'viewer'"`
+}
+
+// userMigrations bring a users table created by an earlier version up to
+// date, since CreateTable leaves existing tables alone. The status used to
+// be a boolean, true for active accounts, and editors, a role that granted
+// nothing more than viewer, become viewers.
+var userMigrations = []string{
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS status_reason VARCHAR`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR NOT NULL DEFAULT 'viewer'`,
+	`UPDATE users SET role = 'viewer' WHERE role = 'editor'`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ`,
+	`DO $$
+	BEGIN
+		IF EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = 'users'
+				AND column_name = 'status' AND data_type = 'boolean'
+		) THEN
+			ALTER TABLE users ALTER COLUMN status DROP DEFAULT;
+			ALTER TABLE users ALTER COLUMN status TYPE VARCHAR
+				USING CASE WHEN status THEN 'active' ELSE 'disabled' END;
+			UPDATE users SET status_reason = ? WHERE status = 'disabled' AND status_reason IS NULL;
+		END IF;
+	END $$`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS status VARCHAR`,
+	`UPDATE users SET status = 'active' WHERE status IS NULL`,
+	`ALTER TABLE users ALTER COLUMN status SET DEFAULT 'active'`,
+	`ALTER TABLE users ALTER COLUMN status SET NOT NULL`,
+}
+
+// MigrateUsers adds the columns that accounts gained since the users table
+// was first created and converts the old boolean status.
+func MigrateUsers(ctx context.Context, db *bun.DB) error {
+	for _, query := range userMigrations {
+		if _, err := db.ExecContext(ctx, query, domain.DisabledByAdminReason); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func ToUserDomain(u UserBun) domain.User {
 
 	return domain.User{
-		ID:       u.ID,
-		Username: u.Username,
-		Password: u.Password,
-		Email:    u.Email,
-		Status:   u.Status,
+		ID:           u.ID,
+		Username:     u.Username,
+		Password:     u.Password,
+		Email:        u.Email,
+		Status:       domain.UserStatus(u.Status),
+		StatusReason: u.StatusReason,
+		LockedUntil:  u.LockedUntil,
+		Role:         domain.Role(u.Role),
 	}
 
 }
@@ -37,11 +82,14 @@ func ToUserDomain(u UserBun) domain.User {
 func FromUserDomain(user domain.User) (*UserBun, error) {
 
 	return &UserBun{
-		ID:       user.ID, // This is a placeholder. You should implement a proper conversion from string to int64.
-		Username: user.Username,
-		Password: user.Password,
-		Email:    user.Email,
-		Status:   user.Status,
+		ID:           user.ID,
+		Username:     user.Username,
+		Password:     user.Password,
+		Email:        user.Email,
+		Status:       string(user.Status),
+		StatusReason: user.StatusReason,
+		LockedUntil:  user.LockedUntil,
+		Role:         string(user.Role),
 	}, nil
 }
 
@@ -61,28 +109,41 @@ func (r UserBunRepository) GetUsers(ctx context.Context) ([]domain.User, error)
 
 func (r UserBunRepository) GetUser(ctx context.Context, id string) (domain.User, error) {
 
-	var user UserBun
-	err := r.DB.NewSelect().Model(&user).Where("id = ?", id).Scan(ctx)
+	userID, err := parseID(id)
 	if err != nil {
 		return domain.User{}, err
 	}
 
+	var user UserBun
+	err = r.DB.NewSelect().Model(&user).Where("id = ?", userID).Scan(ctx)
+	if err != nil {
+		return domain.User{}, translateNotFound(err, domain.ErrUserNotFound)
+	}
+
 	return ToUserDomain(user), nil
 }
 
-
-
-func (r UserBunRepository) GetByUsername(ctx context.Context, username string) (domain.User, error)	 {
+func (r UserBunRepository) GetByUsername(ctx context.Context, username string) (domain.User, error) {
 
 	var user UserBun
 	err := r.DB.NewSelect().Model(&user).Where("username = ?", username).Scan(ctx)
 	if err != nil {
-		return domain.User{}, err
+		return domain.User{}, translateNotFound(err, domain.ErrUserNotFound)
 	}
 
 	return ToUserDomain(user), nil
 }
 
+func (r UserBunRepository) GetByEmail(ctx context.Context, email string) (domain.User, error) {
+
+	var user UserBun
+	err := r.DB.NewSelect().Model(&user).Where("email = ?", email).Scan(ctx)
+	if err != nil {
+		return domain.User{}, translateNotFound(err, domain.ErrUserNotFound)
+	}
+
+	return ToUserDomain(user), nil
+}
 
 func (r UserBunRepository) CreateUser(ctx context.Context, user domain.User) error {
 
@@ -93,11 +154,11 @@ func (r UserBunRepository) CreateUser(ctx context.Context, user domain.User) err
 
 	_, err = r.DB.NewInsert().Model(userBun).Exec(ctx)
 	if err != nil {
-		return err
+		return translateUserConflict(err)
 	}
 
 	return nil
-}	
+}
 
 func (r UserBunRepository) UpdateUser(ctx context.Context, user domain.User) error {
 
@@ -106,30 +167,82 @@ func (r UserBunRepository) UpdateUser(ctx context.Context, user domain.User) err
 		return err
 	}
 
-	_, err = r.DB.NewUpdate().Model(userBun).Where("id = ?", userBun.ID).Exec(ctx)
+	res, err := r.DB.NewUpdate().Model(userBun).WherePK().Exec(ctx)
 	if err != nil {
-		return err
+		return translateUserConflict(err)
 	}
 
-	return nil
+	return checkAffected(res, domain.ErrUserNotFound)
 }
 
-func (r UserBunRepository) UpdateUserStatus(ctx context.Context, id string) error {
+func (r UserBunRepository) UpdateUserStatus(ctx context.Context, id string, status domain.UserStatus, reason string) error {
 
-	_, err := r.DB.NewUpdate().Model((*UserBun)(nil)).SetColumn("status", "NOT status").Where("id = ?", id).Exec(ctx)
+	userID, err := parseID(id)
 	if err != nil {
-		return err		
+		return err
 	}
 
-	return nil
+	res, err := r.DB.NewUpdate().Model((*UserBun)(nil)).
+		Set("status = ?", status).
+		Set("status_reason = ?", reason).
+		Set("locked_until = NULL").
+		Where("id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res, domain.ErrUserNotFound)
 }
 
 func (r UserBunRepository) DeleteUser(ctx context.Context, id string) error {
 
-	_, err := r.DB.NewDelete().Model((*UserBun)(nil)).Where("id = ?", id).Exec(ctx)
+	userID, err := parseID(id)
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
\ No newline at end of file
+	res, err := r.DB.NewDelete().Model((*UserBun)(nil)).Where("id = ?", userID).Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res, domain.ErrUserNotFound)
+}
+
+// LockUser locks an account until the given time.
+func (r UserBunRepository) LockUser(ctx context.Context, id string, reason string, until time.Time) error {
+	userID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.DB.NewUpdate().Model((*UserBun)(nil)).
+		Set("status = ?", domain.StatusLocked).
+		Set("status_reason = ?", reason).
+		Set("locked_until = ?", until).
+		Where("id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res, domain.ErrUserNotFound)
+}
+
+func (r UserBunRepository) CountUsers(ctx context.Context) (int, error) {
+	return r.DB.NewSelect().Model((*UserBun)(nil)).Count(ctx)
+}
+
+// translateUserConflict maps a violation of the unique username or email
+// constraints, when two requests race past the service checks, to the
+// matching domain error.
+func translateUserConflict(err error) error {
+	switch uniqueViolation(err) {
+	case "users_username_key":
+		return domain.ErrUsernameTaken
+	case "users_email_key":
+		return domain.ErrEmailTaken
+	}
+	return err
+}
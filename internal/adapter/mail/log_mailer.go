@@ -0,0 +1,42 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"spahtmx/internal/domain"
+	"time"
+)
+
+// LogMailer stands in for a mail server during local development. It logs
+// the recipient and subject of each email and, when Dir is set, writes it
+// there as an .eml file that a mail client can open. The body is never
+// logged, since it carries verification and password reset links.
+type LogMailer struct {
+	Dir  string
+	From string
+}
+
+func (m *LogMailer) Send(ctx context.Context, email domain.Email) error {
+	if m.Dir == "" {
+		slog.Info("Email not sent, no mail server configured", "to", email.To, "subject", email.Subject)
+		return nil
+	}
+
+	now := time.Now()
+	msg, err := formatMessage(m.From, email, now)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(m.Dir, fmt.Sprintf("%s-%d.eml", now.Format("20060102-150405"), now.UnixNano()%1e9))
+	if err := os.WriteFile(path, msg, 0o600); err != nil {
+		return err
+	}
+	slog.Info("Email written to file", "to", email.To, "subject", email.Subject, "file", path)
+	return nil
+}
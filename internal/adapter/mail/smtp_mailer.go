@@ -0,0 +1,43 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+	"spahtmx/internal/domain"
+	"time"
+)
+
+// SMTPMailer sends emails through an SMTP server, authenticating when a
+// username is set. The connection is upgraded with STARTTLS when the server
+// offers it.
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, email domain.Email) error {
+	msg, err := formatMessage(m.From, email, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth, m.From, []string{email.To}, msg)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -3,46 +3,84 @@ package web
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"net/http"
-	"sort"
+	"net/url"
 	"spahtmx/internal/adapter/web/templates"
 	"spahtmx/internal/app"
 	"spahtmx/internal/config"
 	"spahtmx/internal/domain"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/a-h/templ"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 )
 
 const (
-	RouteIndex  = "/"
-	RouteAdmin  = "/admin"
-	RouteAbout  = "/about"
-	RouteStatus = "/status"
-	RoutePrize  = "/prize"
-	RouteLogin  = "/login"
-	RouteLogout = "/logout"
-	RouteSwitch = "/api/switch/:id"
-	RouteStatic = "/static"
+	RouteIndex            = "/"
+	RouteAdmin            = "/admin"
+	RouteAnalytics        = "/admin/analytics"
+	RouteUsers            = "/admin/users"
+	RouteUser             = "/admin/users/:id"
+	RouteUserEdit         = "/admin/users/:id/edit"
+	RouteUserUnlock       = "/admin/users/:id/unlock"
+	RouteLoginBlocks      = "/admin/login-blocks"
+	RouteRolePolicies     = "/admin/role-policies"
+	RouteAbout            = "/about"
+	RouteStatus           = "/status"
+	RoutePrize            = "/prize"
+	RoutePrizeDetail      = "/prize/:id"
+	RouteLaureate         = "/laureate/:id"
+	RouteStats            = "/stats"
+	RouteLogin            = "/login"
+	RouteLoginCode        = "/login/2fa"
+	RouteLogout           = "/logout"
+	RouteRegister         = "/register"
+	RouteVerifyEmail      = "/verify-email"
+	RouteForgot           = "/forgot-password"
+	RouteReset            = "/reset-password"
+	RouteSessions         = "/sessions"
+	RouteSession          = "/sessions/:id"
+	RouteLogoutAll        = "/sessions/logout-all"
+	RouteTwoFactor        = "/two-factor"
+	RouteTwoFactorEnroll  = "/two-factor/enroll"
+	RouteTwoFactorConfirm = "/two-factor/confirm"
+	RouteRecoveryCodes    = "/two-factor/recovery-codes"
+	RouteTwoFactorDisable = "/two-factor/disable"
+	RouteSwitch           = "/api/switch/:id"
+	RouteStatic           = "/static"
 )
 
 type Handler struct {
-	userService  *app.UserService
-	prizeService *app.PrizeService
-	authService  *app.AuthService
-	config       *config.Config
+	userService     *app.UserService
+	prizeService    *app.PrizeService
+	authService     *app.AuthService
+	pageViewService *app.PageViewService
+	lockoutService  *app.LockoutService
+	auditService    *app.AuditService
+	registration    *app.RegistrationService
+	passwordReset   *app.PasswordResetService
+	twoFactor       *app.TwoFactorService
+	config          *config.Config
 }
 
-func NewHandler(userService *app.UserService, prizeService *app.PrizeService, authService *app.AuthService, cfg *config.Config) *Handler {
+func NewHandler(userService *app.UserService, prizeService *app.PrizeService, authService *app.AuthService, pageViewService *app.PageViewService, lockoutService *app.LockoutService, auditService *app.AuditService, registration *app.RegistrationService, passwordReset *app.PasswordResetService, twoFactor *app.TwoFactorService, cfg *config.Config) *Handler {
 	return &Handler{
-		userService:  userService,
-		prizeService: prizeService,
-		authService:  authService,
-		config:       cfg,
+		userService:     userService,
+		prizeService:    prizeService,
+		authService:     authService,
+		pageViewService: pageViewService,
+		lockoutService:  lockoutService,
+		auditService:    auditService,
+		registration:    registration,
+		passwordReset:   passwordReset,
+		twoFactor:       twoFactor,
+		config:          cfg,
 	}
 }
 
@@ -54,46 +92,229 @@ func (h *Handler) HandleLoginPost(c echo.Context) error {
 	username := c.FormValue("username")
 	password := c.FormValue("password")
 
-	user, err := h.authService.Login(c.Request().Context(), username, password)
+	result, err := h.authService.Login(c.Request().Context(), username, password, c.RealIP())
 	if err != nil {
-		return h.handlePage(c, RouteLogin, templates.Login("Identifiants incorrects"))
+		message := loginErrorMessage(c, err)
+		if message == "" {
+			message = "Identifiants incorrects"
+		}
+		return h.handlePage(c, RouteLogin, templates.Login(message))
 	}
 
-	// Génération du JWT
-	tokenString, err := h.authService.GenerateToken(user.Username, h.config.JWTSecret)
+	if !result.Complete() {
+		return h.renderLoginCode(c, result, "")
+	}
+	return h.completeLogin(c, result)
+}
+
+// HandleLoginCodePost completes a login with a two-factor code.
+func (h *Handler) HandleLoginCodePost(c echo.Context) error {
+	result, err := h.authService.LoginSecondFactor(c.Request().Context(), c.FormValue("challenge"), c.FormValue("code"), c.RealIP())
+	if errors.Is(err, domain.ErrInvalidCode) {
+		return h.renderLoginCode(c, result, "Code incorrect")
+	}
 	if err != nil {
-		slog.Error("Failed to generate token", "error", err)
-		return h.handlePage(c, RouteLogin, templates.Login("Erreur interne de connexion"))
+		message := loginErrorMessage(c, err)
+		if message == "" && errors.Is(err, app.ErrUnauthorized) {
+			message = "La vérification a expiré, reconnectez-vous"
+		} else if message == "" {
+			slog.Error("Failed to check two-factor code", "error", err)
+			message = "Erreur interne de connexion"
+		}
+		return h.loginError(c, message)
+	}
+	return h.completeLogin(c, result)
+}
+
+// loginErrorMessage explains why a login was refused, or returns "" for
+// other errors. A blocked login also sets the Retry-After header.
+func loginErrorMessage(c echo.Context, err error) string {
+	var blocked *domain.LoginBlockedError
+	switch {
+	case errors.As(err, &blocked):
+		retryAfter := time.Until(blocked.Until)
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		return loginBlockedMessage(retryAfter)
+	case errors.Is(err, domain.ErrAccountDisabled):
+		return "Ce compte est désactivé"
+	case errors.Is(err, domain.ErrAccountLocked):
+		return "Ce compte est verrouillé"
+	case errors.Is(err, domain.ErrAccountPending):
+		return "Ce compte n'est pas encore activé : suivez le lien reçu par email"
 	}
+	return ""
+}
+
+// loginError shows the login page with an error, in place of the whole
+// content even when the request came from the two-factor step.
+func (h *Handler) loginError(c echo.Context, message string) error {
+	c.Response().Header().Set("HX-Retarget", "#content")
+	c.Response().Header().Set("HX-Reswap", "innerHTML")
+	return h.handlePage(c, RouteLogin, templates.Login(message))
+}
+
+// renderLoginCode shows the two-factor step of a login in place of the
+// login card.
+func (h *Handler) renderLoginCode(c echo.Context, result app.LoginResult, errorMsg string) error {
+	var uri, secret string
+	if result.Enrollment != nil {
+		uri, secret = result.Enrollment.URI, result.Enrollment.Secret
+	}
+	if c.Request().Header.Get("HX-Request") != "true" {
+		return h.handlePage(c, RouteLogin, templates.LoginCodePage(result.Challenge, uri, secret, errorMsg))
+	}
+	// Seule la carte de connexion est remplacée, la navigation ne change pas
+	c.Response().Header().Set("HX-Retarget", "#login-card")
+	c.Response().Header().Set("HX-Reswap", "outerHTML")
+	return h.handleFragment(c, templates.LoginCode(result.Challenge, uri, secret, errorMsg))
+}
+
+// completeLogin opens a session for the logged-in user and sends the
+// browser to its landing page, after showing the recovery codes of an
+// authenticator app set up while logging in.
+func (h *Handler) completeLogin(c echo.Context, result app.LoginResult) error {
+	user := result.User
 
-	cookie := new(http.Cookie)
-	cookie.Name = "session"
-	cookie.Value = tokenString
-	cookie.Path = "/"
-	cookie.Expires = time.Now().Add(24 * time.Hour)
-	cookie.HttpOnly = true
-	cookie.SameSite = http.SameSiteLaxMode
-	c.SetCookie(cookie)
+	// Ouverture d'une session côté serveur, référencée par le JWT
+	session, refreshToken, err := h.authService.StartSession(c.Request().Context(), user, c.Request().UserAgent())
+	if err != nil {
+		slog.Error("Failed to start session", "error", err)
+		return h.loginError(c, "Erreur interne de connexion")
+	}
+
+	// Jeton d'accès de courte durée, renouvelé grâce au jeton de rafraîchissement
+	if err := setSessionCookies(c, h.authService, user, session, refreshToken); err != nil {
+		slog.Error("Failed to generate token", "error", err)
+		return h.loginError(c, "Erreur interne de connexion")
+	}
 
 	// On stocke l'utilisateur dans le contexte pour handlePage
-	c.Set("user", user)
+	c.Set(ContextUser, user)
+
+	// Seuls les administrateurs ont accès au panneau d'administration
+	target := RouteIndex
+	if user.Role.Includes(domain.RoleAdmin) {
+		target = RouteAdmin
+	}
+
+	// Les codes de secours ne sont affichés qu'une fois, avant de poursuivre
+	if len(result.RecoveryCodes) > 0 {
+		return h.handleFragment(c, templates.LoginRecoveryCodes(result.RecoveryCodes, target))
+	}
 
 	if c.Request().Header.Get("HX-Request") == "true" {
-		c.Response().Header().Set("HX-Redirect", RouteAdmin)
+		c.Response().Header().Set("HX-Redirect", target)
 		return c.NoContent(http.StatusOK)
 	}
-	return c.Redirect(http.StatusSeeOther, RouteAdmin)
+	return c.Redirect(http.StatusSeeOther, target)
+}
+
+// loginBlockedMessage tells how long to wait before trying to log in again.
+func loginBlockedMessage(retryAfter time.Duration) string {
+	if retryAfter < time.Minute {
+		return "Trop de tentatives de connexion. Réessayez dans une minute."
+	}
+	minutes := int(math.Ceil(retryAfter.Minutes()))
+	return fmt.Sprintf("Trop de tentatives de connexion. Réessayez dans %d minutes.", minutes)
+}
+
+func (h *Handler) HandleRegisterPage(c echo.Context) error {
+	return h.handlePage(c, RouteRegister, templates.Register(templates.RegisterForm{}))
+}
+
+func (h *Handler) HandleRegisterPost(c echo.Context) error {
+	in := app.RegistrationInput{
+		Username:        c.FormValue("username"),
+		Email:           c.FormValue("email"),
+		Password:        c.FormValue("password"),
+		PasswordConfirm: c.FormValue("password_confirm"),
+	}
+
+	user, err := h.registration.Register(c.Request().Context(), in)
+	if err != nil {
+		message := userErrorMessage(err)
+		if message == "" {
+			slog.Error("Failed to register user", "error", err)
+			message = "L'inscription a échoué, réessayez plus tard."
+		}
+		// Le formulaire est réaffiché avec les valeurs saisies, sauf le mot de passe
+		return h.handlePage(c, RouteRegister, templates.Register(templates.RegisterForm{
+			Username: in.Username,
+			Email:    in.Email,
+			Error:    message,
+		}))
+	}
+
+	return h.handlePage(c, RouteRegister, templates.RegisterSent(user.Email))
+}
+
+// HandleVerifyEmail activates the account of the emailed verification link.
+func (h *Handler) HandleVerifyEmail(c echo.Context) error {
+	_, err := h.registration.VerifyEmail(c.Request().Context(), c.QueryParam("token"))
+	switch {
+	case errors.Is(err, domain.ErrInvalidToken):
+		return h.handlePage(c, RouteVerifyEmail, templates.EmailVerified("Ce lien de vérification est invalide ou a expiré."))
+	case errors.Is(err, domain.ErrAccountDisabled), errors.Is(err, domain.ErrAccountLocked):
+		return h.handlePage(c, RouteVerifyEmail, templates.EmailVerified("Ce compte a été désactivé."))
+	case err != nil:
+		return translateError(err)
+	}
+	return h.handlePage(c, RouteVerifyEmail, templates.EmailVerified(""))
+}
+
+func (h *Handler) HandleForgotPasswordPage(c echo.Context) error {
+	return h.handlePage(c, RouteForgot, templates.ForgotPassword(false, ""))
+}
+
+// HandleForgotPasswordPost answers the same way whether or not an account
+// uses the email.
+func (h *Handler) HandleForgotPasswordPost(c echo.Context) error {
+	if err := h.passwordReset.RequestReset(c.Request().Context(), c.FormValue("email")); err != nil {
+		return translateError(err)
+	}
+	return h.handlePage(c, RouteForgot, templates.ForgotPassword(true, ""))
+}
+
+func (h *Handler) HandleResetPasswordPage(c echo.Context) error {
+	// Le jeton figure dans l'URL : il ne doit pas fuiter via l'en-tête Referer
+	c.Response().Header().Set("Referrer-Policy", "no-referrer")
+	return h.handlePage(c, RouteReset, templates.ResetPassword(c.QueryParam("token"), ""))
+}
+
+func (h *Handler) HandleResetPasswordPost(c echo.Context) error {
+	token := c.FormValue("token")
+	err := h.passwordReset.ResetPassword(c.Request().Context(), token, c.FormValue("password"), c.FormValue("password_confirm"))
+	if errors.Is(err, domain.ErrInvalidToken) {
+		return h.handlePage(c, RouteReset, templates.ForgotPassword(false, "Ce lien est invalide, a expiré ou a déjà servi. Demandez-en un nouveau."))
+	}
+	if err != nil {
+		message := userErrorMessage(err)
+		if message == "" {
+			return translateError(err)
+		}
+		return h.handlePage(c, RouteReset, templates.ResetPassword(token, message))
+	}
+
+	// Toutes les sessions viennent d'être révoquées, y compris celle-ci
+	clearSessionCookies(c)
+	c.Set("logout", true)
+	return h.handlePage(c, RouteReset, templates.PasswordResetDone())
 }
 
 func (h *Handler) HandleLogout(c echo.Context) error {
-	cookie := new(http.Cookie)
-	cookie.Name = "session"
-	cookie.Value = ""
-	cookie.Path = "/"
-	cookie.MaxAge = -1
-	cookie.HttpOnly = true
-	cookie.SameSite = http.SameSiteLaxMode
-	c.SetCookie(cookie)
+	// La session est révoquée côté serveur, le jeton ne sert plus à rien
+	var accessToken, refreshToken string
+	if cookie, err := c.Cookie(accessCookie); err == nil {
+		accessToken = cookie.Value
+	}
+	if cookie, err := c.Cookie(refreshCookie); err == nil {
+		refreshToken = cookie.Value
+	}
+	if err := h.authService.Logout(c.Request().Context(), accessToken, refreshToken); err != nil {
+		return translateError(err)
+	}
+
+	clearSessionCookies(c)
 
 	// On marque explicitement qu'il n'y a plus d'utilisateur pour handlePage
 	c.Set("user", nil)
@@ -102,6 +323,153 @@ func (h *Handler) HandleLogout(c echo.Context) error {
 	return h.HandleIndexPage(c)
 }
 
+// HandleSessionsPage lists the active sessions of the logged-in user and,
+// for administrators, those of every user.
+func (h *Handler) HandleSessionsPage(c echo.Context) error {
+	user, _ := c.Get(ContextUser).(domain.User)
+	current, _ := c.Get(ContextSession).(domain.Session)
+
+	sessions, err := h.authService.GetUserSessions(c.Request().Context(), user.ID)
+	if err != nil {
+		return translateError(err)
+	}
+
+	var all []domain.Session
+	if user.Role.Includes(domain.RoleAdmin) {
+		all, err = h.authService.GetActiveSessions(c.Request().Context())
+		if err != nil {
+			return translateError(err)
+		}
+	}
+
+	return h.handlePage(c, RouteSessions, templates.Sessions(sessions, all, current.ID))
+}
+
+// HandleSessionRevoke logs out one session. Users may revoke their own
+// sessions, administrators any session. Revoking the current session logs
+// the user out.
+func (h *Handler) HandleSessionRevoke(c echo.Context) error {
+	user, _ := c.Get(ContextUser).(domain.User)
+	current, _ := c.Get(ContextSession).(domain.Session)
+
+	session, err := h.authService.GetSession(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return translateError(err)
+	}
+	if session.UserID != user.ID && !user.Role.Includes(domain.RoleAdmin) {
+		return echo.NewHTTPError(http.StatusForbidden, "Forbidden")
+	}
+
+	if err := h.authService.RevokeSession(c.Request().Context(), session.ID); err != nil {
+		return translateError(err)
+	}
+
+	if session.ID == current.ID {
+		clearSessionCookies(c)
+		c.Response().Header().Set("HX-Redirect", RouteLogin)
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// HandleLogoutAll logs the user out of every device, this one included.
+func (h *Handler) HandleLogoutAll(c echo.Context) error {
+	user, _ := c.Get(ContextUser).(domain.User)
+
+	if err := h.authService.RevokeAllSessions(c.Request().Context(), user.ID); err != nil {
+		return translateError(err)
+	}
+
+	clearSessionCookies(c)
+	if c.Request().Header.Get("HX-Request") == "true" {
+		c.Response().Header().Set("HX-Redirect", RouteLogin)
+		return c.NoContent(http.StatusOK)
+	}
+	return c.Redirect(http.StatusSeeOther, RouteLogin)
+}
+
+// HandleTwoFactorPage lets the logged-in user manage their authenticator
+// app and recovery codes.
+func (h *Handler) HandleTwoFactorPage(c echo.Context) error {
+	user, _ := c.Get(ContextUser).(domain.User)
+
+	status, err := h.twoFactor.GetStatus(c.Request().Context(), user)
+	if err != nil {
+		return translateError(err)
+	}
+	return h.handlePage(c, RouteTwoFactor, templates.TwoFactor(status.Enabled, status.Required, status.RecoveryCodes))
+}
+
+// HandleTwoFactorEnroll shows the QR code of a new authenticator app.
+func (h *Handler) HandleTwoFactorEnroll(c echo.Context) error {
+	user, _ := c.Get(ContextUser).(domain.User)
+
+	enrollment, err := h.twoFactor.Enroll(c.Request().Context(), user)
+	if err != nil {
+		return translateError(err)
+	}
+	return h.handleFragment(c, templates.TwoFactorEnroll(enrollment.URI, enrollment.Secret, ""))
+}
+
+// HandleTwoFactorConfirm enables the app being set up once its first code
+// is right, and shows the recovery codes.
+func (h *Handler) HandleTwoFactorConfirm(c echo.Context) error {
+	user, _ := c.Get(ContextUser).(domain.User)
+
+	codes, err := h.twoFactor.Confirm(c.Request().Context(), user, c.FormValue("code"))
+	if errors.Is(err, domain.ErrInvalidCode) {
+		enrollment, err := h.twoFactor.Enroll(c.Request().Context(), user)
+		if err != nil {
+			return translateError(err)
+		}
+		return h.handleFragment(c, templates.TwoFactorEnroll(enrollment.URI, enrollment.Secret, "Code incorrect"))
+	}
+	if err != nil {
+		return translateError(err)
+	}
+	return h.handleFragment(c, templates.RecoveryCodes(codes))
+}
+
+// HandleRecoveryCodes replaces the recovery codes of the user.
+func (h *Handler) HandleRecoveryCodes(c echo.Context) error {
+	user, _ := c.Get(ContextUser).(domain.User)
+
+	codes, err := h.twoFactor.RegenerateRecoveryCodes(c.Request().Context(), user, c.FormValue("code"))
+	if err != nil {
+		return h.handleTwoFactorError(c, user, err)
+	}
+	return h.handleFragment(c, templates.RecoveryCodes(codes))
+}
+
+// HandleTwoFactorDisable removes the authenticator app of the user.
+func (h *Handler) HandleTwoFactorDisable(c echo.Context) error {
+	user, _ := c.Get(ContextUser).(domain.User)
+
+	if err := h.twoFactor.Disable(c.Request().Context(), user, c.FormValue("code")); err != nil {
+		return h.handleTwoFactorError(c, user, err)
+	}
+	return h.handleFragment(c, templates.TwoFactorPanel(false, false, 0, ""))
+}
+
+// handleTwoFactorError shows the two-factor panel again with the reason an
+// action was refused.
+func (h *Handler) handleTwoFactorError(c echo.Context, user domain.User, err error) error {
+	var message string
+	switch {
+	case errors.Is(err, domain.ErrInvalidCode):
+		message = "Code incorrect"
+	case errors.Is(err, domain.ErrTwoFactorNeeded):
+		message = "Votre rôle exige la double authentification"
+	default:
+		return translateError(err)
+	}
+
+	status, err := h.twoFactor.GetStatus(c.Request().Context(), user)
+	if err != nil {
+		return translateError(err)
+	}
+	return h.handleFragment(c, templates.TwoFactorPanel(status.Enabled, status.Required, status.RecoveryCodes, message))
+}
+
 func (h *Handler) HandleIndexPage(c echo.Context) error {
 	return h.handlePage(c, RouteIndex, templates.Index())
 }
@@ -112,10 +480,232 @@ func (h *Handler) HandleAdminPage(c echo.Context) error {
 	if err != nil {
 		return translateError(err)
 	}
-	usersCount := h.userService.GetUserCount(c.Request().Context())
-	pageViews := h.userService.GetPageView(c.Request().Context())
+	usersCount, err := h.userService.GetUserCount(c.Request().Context())
+	if err != nil {
+		return translateError(err)
+	}
+	pageViews, err := h.pageViewService.GetTotals(c.Request().Context())
+	if err != nil {
+		return translateError(err)
+	}
+	blocked, err := h.lockoutService.GetBlocked(c.Request().Context())
+	if err != nil {
+		return translateError(err)
+	}
+	policies, err := h.twoFactor.GetPolicies(c.Request().Context())
+	if err != nil {
+		return translateError(err)
+	}
+	audit, err := h.auditService.GetRecent(c.Request().Context())
+	if err != nil {
+		return translateError(err)
+	}
 
-	return h.handlePage(c, RouteAdmin, templates.Admin(users, usersCount, pageViews))
+	return h.handlePage(c, RouteAdmin, templates.Admin(users, usersCount, pageViews, blocked, policies, audit))
+}
+
+// HandleUserUnlock reactivates an account locked after failed logins and
+// returns its row, along with the updated audit log.
+func (h *Handler) HandleUserUnlock(c echo.Context) error {
+	admin, _ := c.Get(ContextUser).(domain.User)
+
+	user, err := h.lockoutService.UnlockUser(c.Request().Context(), admin.Username, c.Param("id"))
+	if err != nil {
+		return translateError(err)
+	}
+
+	return h.handleWithAuditLog(c, templates.UserRow(user))
+}
+
+// HandleLoginUnblock lets logins for a blocked username or address through
+// again and returns the remaining blocks, along with the updated audit log.
+func (h *Handler) HandleLoginUnblock(c echo.Context) error {
+	admin, _ := c.Get(ContextUser).(domain.User)
+
+	if err := h.lockoutService.Unblock(c.Request().Context(), admin.Username, c.FormValue("key")); err != nil {
+		return translateError(err)
+	}
+	blocked, err := h.lockoutService.GetBlocked(c.Request().Context())
+	if err != nil {
+		return translateError(err)
+	}
+
+	return h.handleWithAuditLog(c, templates.LoginBlocks(blocked))
+}
+
+// HandleRolePolicy changes whether a role requires two-factor
+// authentication and returns the policies, along with the updated audit
+// log. An administrator whose own session ends with the change is sent to
+// the login page.
+func (h *Handler) HandleRolePolicy(c echo.Context) error {
+	admin, _ := c.Get(ContextUser).(domain.User)
+	current, _ := c.Get(ContextSession).(domain.Session)
+
+	require, err := strconv.ParseBool(c.FormValue("require"))
+	if err != nil {
+		return translateError(domain.ErrInvalidInput)
+	}
+	role := domain.Role(c.FormValue("role"))
+	if err := h.twoFactor.SetPolicy(c.Request().Context(), admin.Username, role, require); err != nil {
+		return translateError(err)
+	}
+
+	if _, err := h.authService.GetSession(c.Request().Context(), current.ID); errors.Is(err, domain.ErrSessionNotFound) {
+		clearSessionCookies(c)
+		c.Response().Header().Set("HX-Redirect", RouteLogin)
+		return c.NoContent(http.StatusOK)
+	} else if err != nil {
+		return translateError(err)
+	}
+
+	policies, err := h.twoFactor.GetPolicies(c.Request().Context())
+	if err != nil {
+		return translateError(err)
+	}
+	return h.handleWithAuditLog(c, templates.RolePolicies(policies))
+}
+
+// handleWithAuditLog renders a fragment and swaps in the latest audit log
+// out of band, so that the admin panel shows the entry just recorded.
+func (h *Handler) handleWithAuditLog(c echo.Context, contents templ.Component) error {
+	audit, err := h.auditService.GetRecent(c.Request().Context())
+	if err != nil {
+		return translateError(err)
+	}
+
+	return h.handleFragment(c, templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		if err := contents.Render(ctx, w); err != nil {
+			return err
+		}
+		return templates.AuditLog(audit, true).Render(ctx, w)
+	}))
+}
+
+func userInput(c echo.Context) app.UserInput {
+	return app.UserInput{
+		Username:     c.FormValue("username"),
+		Email:        c.FormValue("email"),
+		Password:     c.FormValue("password"),
+		Status:       domain.UserStatus(c.FormValue("status")),
+		StatusReason: c.FormValue("reason"),
+		Role:         domain.Role(c.FormValue("role")),
+	}
+}
+
+// userErrorMessage explains a rejected user form, or returns "" when the
+// error is not caused by the submitted values.
+func userErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrUsernameTaken):
+		return "Ce nom d'utilisateur est déjà pris."
+	case errors.Is(err, domain.ErrEmailTaken):
+		return "Cet email est déjà utilisé."
+	case errors.Is(err, domain.ErrInvalidUsername):
+		return "Le nom d'utilisateur doit compter de 3 à 32 lettres, chiffres, '.', '_' ou '-'."
+	case errors.Is(err, domain.ErrInvalidEmail):
+		return "L'adresse email n'est pas valide."
+	case errors.Is(err, domain.ErrPasswordTooShort):
+		return "Le mot de passe doit compter au moins " + strconv.Itoa(domain.MinPasswordLength) + " caractères."
+	case errors.Is(err, domain.ErrWeakPassword):
+		return "Ce mot de passe est trop facile à deviner."
+	case errors.Is(err, domain.ErrPasswordMismatch):
+		return "Les deux mots de passe ne correspondent pas."
+	case errors.Is(err, domain.ErrLastAdmin):
+		return "Il doit rester au moins un administrateur actif."
+	}
+	return ""
+}
+
+func (h *Handler) HandleUserCreate(c echo.Context) error {
+	in := userInput(c)
+	if _, err := h.userService.CreateUser(c.Request().Context(), in); err != nil {
+		message := userErrorMessage(err)
+		if message == "" {
+			return translateError(err)
+		}
+		// Seul le formulaire est remplacé pour afficher l'erreur
+		c.Response().Header().Set("HX-Retarget", "#user-create-form")
+		c.Response().Header().Set("HX-Reswap", "outerHTML")
+		return h.handleFragment(c, templates.UserCreateForm(templates.UserForm{
+			Username:     in.Username,
+			Email:        in.Email,
+			Status:       in.Status,
+			StatusReason: in.StatusReason,
+			Role:         in.Role,
+			Error:        message,
+		}))
+	}
+
+	users, err := h.userService.GetUsers(c.Request().Context())
+	if err != nil {
+		return translateError(err)
+	}
+	return h.handleFragment(c, templates.Userlist(users))
+}
+
+func (h *Handler) HandleUserRow(c echo.Context) error {
+	user, err := h.userService.GetUser(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return translateError(err)
+	}
+	return h.handleFragment(c, templates.UserRow(user))
+}
+
+func (h *Handler) HandleUserEditForm(c echo.Context) error {
+	user, err := h.userService.GetUser(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return translateError(err)
+	}
+	return h.handleFragment(c, templates.UserEditRow(user, templates.UserForm{
+		Username:     user.Username,
+		Email:        user.Email,
+		Status:       user.Status,
+		StatusReason: user.StatusReason,
+		Role:         user.Role,
+	}))
+}
+
+func (h *Handler) HandleUserUpdate(c echo.Context) error {
+	in := userInput(c)
+	user, err := h.userService.UpdateUser(c.Request().Context(), c.Param("id"), in)
+	if err != nil {
+		message := userErrorMessage(err)
+		if message == "" {
+			return translateError(err)
+		}
+		current, err := h.userService.GetUser(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return translateError(err)
+		}
+		return h.handleFragment(c, templates.UserEditRow(current, templates.UserForm{
+			Username:     in.Username,
+			Email:        in.Email,
+			Status:       in.Status,
+			StatusReason: in.StatusReason,
+			Role:         in.Role,
+			Error:        message,
+		}))
+	}
+	return h.handleFragment(c, templates.UserRow(user))
+}
+
+func (h *Handler) HandleUserDelete(c echo.Context) error {
+	if err := h.userService.DeleteUser(c.Request().Context(), c.Param("id")); err != nil {
+		return translateError(err)
+	}
+	// Réponse vide : htmx retire la ligne de l'utilisateur
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *Handler) HandleAnalyticsPage(c echo.Context) error {
+	days, _ := strconv.Atoi(c.QueryParam("days"))
+
+	report, err := h.pageViewService.GetReport(c.Request().Context(), days)
+	if err != nil {
+		return translateError(err)
+	}
+
+	return h.handlePage(c, RouteAdmin, templates.Analytics(report))
 }
 
 func (h *Handler) HandleAboutPage(c echo.Context) error {
@@ -138,8 +728,25 @@ func (h *Handler) HandleUserStatusSwitch(c echo.Context) error {
 }
 
 func (h *Handler) HandlePrizePage(c echo.Context) error {
-	category := c.QueryParam("category")
-	year := c.QueryParam("year")
+	size, _ := strconv.Atoi(c.QueryParam("size"))
+
+	criteria, err := parsePrizeCriteria(c.QueryParams())
+	if err != nil {
+		return translateError(err)
+	}
+	after, err := domain.ParsePrizeCursor(c.QueryParam("after"))
+	if err != nil {
+		return translateError(err)
+	}
+
+	// Pages suivantes du défilement infini : seules les cartes sont renvoyées
+	if !after.IsZero() {
+		page, err := h.prizeService.GetPrizePage(c.Request().Context(), criteria, after, size)
+		if err != nil {
+			return translateError(err)
+		}
+		return h.handleFragment(c, templates.PrizePage(page.Prizes, criteria.Terms(), prizePageURL(criteria, size, page.Next)))
+	}
 
 	categories, err := h.prizeService.GetCategories(c.Request().Context())
 	if err != nil {
@@ -150,37 +757,188 @@ func (h *Handler) HandlePrizePage(c echo.Context) error {
 		return translateError(err)
 	}
 
-	sort.Strings(categories)
-	sort.Slice(years, func(i, j int) bool { return years[i] > years[j] })
+	// Default to the latest year available in the database when the page is
+	// opened without any parameter
+	if len(c.QueryParams()) == 0 && len(years) > 0 {
+		criteria.YearFrom, criteria.YearTo = years[0], years[0]
+	}
 
-	// Default to the latest year available in the database
-	if category == "" && year == "" && len(years) > 0 {
-		year = years[0]
+	page, err := h.prizeService.GetPrizePage(c.Request().Context(), criteria, after, size)
+	if err != nil {
+		return translateError(err)
 	}
 
-	var prizes []domain.Prize
+	more := prizePageURL(criteria, size, page.Next)
 
-	if category != "" && year != "" {
-		prizes, err = h.prizeService.GetPrizesByCategoryAndYear(c.Request().Context(), category, year)
-	} else if category != "" {
-		prizes, err = h.prizeService.GetPrizesByCategory(c.Request().Context(), category)
-	} else if year != "" {
-		prizes, err = h.prizeService.GetPrizesByYear(c.Request().Context(), year)
-	} else {
-		prizes, err = h.prizeService.GetPrizes(c.Request().Context())
+	// La recherche active ne remplace que la grille des prix
+	if c.Request().Header.Get("HX-Target") == "prize-grid-container" {
+		return h.handleFragment(c, templates.PrizeList(page, criteria.Terms(), more))
+	}
+
+	return h.handlePage(c, RoutePrize, templates.Prize(page, categories, years, criteria, more))
+}
+
+// parsePrizeCriteria reads the prize listing filters from the query string.
+// Categories may be repeated; "year" is kept as a shortcut for a range
+// covering a single year.
+func parsePrizeCriteria(params url.Values) (domain.PrizeCriteria, error) {
+	order, err := domain.ParsePrizeSort(params.Get("sort"))
+	if err != nil {
+		return domain.PrizeCriteria{}, err
+	}
+
+	criteria := domain.PrizeCriteria{
+		Query:                strings.TrimSpace(params.Get("q")),
+		HasOverallMotivation: params.Get("motivation") == "true",
+		Sharing:              domain.PrizeSharing(params.Get("sharing")),
+		LaureateKind:         domain.LaureateKind(params.Get("kind")),
+		Sort:                 order,
+	}
+
+	ints := []struct {
+		param string
+		dest  *int
+	}{
+		{"from", &criteria.YearFrom},
+		{"to", &criteria.YearTo},
+		{"year", &criteria.YearFrom},
+		{"min", &criteria.MinLaureates},
+	}
+	for _, p := range ints {
+		if v := params.Get(p.param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return domain.PrizeCriteria{}, domain.ErrInvalidInput
+			}
+			*p.dest = n
+		}
+	}
+	if params.Get("year") != "" {
+		criteria.YearTo = criteria.YearFrom
+	}
+
+	for _, c := range params["category"] {
+		if c == "" {
+			continue
+		}
+		category, err := domain.ParseCategory(c)
+		if err != nil {
+			return domain.PrizeCriteria{}, err
+		}
+		criteria.Categories = append(criteria.Categories, category)
+	}
+
+	return criteria, nil
+}
+
+// prizePageURL builds the URL of the page following next, keeping the current
+// criteria. It returns an empty string on the last page.
+func prizePageURL(criteria domain.PrizeCriteria, size int, next *domain.PrizeCursor) string {
+	if next == nil {
+		return ""
+	}
+
+	v := url.Values{}
+	if criteria.Query != "" {
+		v.Set("q", criteria.Query)
+	}
+	for _, category := range criteria.Categories {
+		v.Add("category", string(category))
+	}
+	if criteria.YearFrom != 0 {
+		v.Set("from", strconv.Itoa(criteria.YearFrom))
+	}
+	if criteria.YearTo != 0 {
+		v.Set("to", strconv.Itoa(criteria.YearTo))
+	}
+	if criteria.HasOverallMotivation {
+		v.Set("motivation", "true")
+	}
+	if criteria.MinLaureates > 0 {
+		v.Set("min", strconv.Itoa(criteria.MinLaureates))
+	}
+	if criteria.Sharing != domain.SharingAny {
+		v.Set("sharing", string(criteria.Sharing))
+	}
+	if criteria.LaureateKind != "" {
+		v.Set("kind", string(criteria.LaureateKind))
+	}
+	if criteria.Sort != domain.SortYearDesc {
+		v.Set("sort", string(criteria.Sort))
+	}
+	if size > 0 {
+		v.Set("size", strconv.Itoa(size))
+	}
+	v.Set("after", next.String())
+
+	return RoutePrize + "?" + v.Encode()
+}
+
+func (h *Handler) HandlePrizeDetailPage(c echo.Context) error {
+	detail, err := h.prizeService.GetPrizeDetail(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return translateError(err)
+	}
+
+	return h.handlePage(c, RoutePrize, templates.PrizeDetail(detail))
+}
+
+func (h *Handler) HandleLaureatePage(c echo.Context) error {
+	profile, err := h.prizeService.GetLaureate(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return translateError(err)
+	}
+
+	return h.handlePage(c, RoutePrize, templates.Laureate(profile))
+}
+
+func (h *Handler) HandleStatsPage(c echo.Context) error {
+	var filter domain.StatsFilter
+	if category := c.QueryParam("category"); category != "" {
+		parsed, err := domain.ParseCategory(category)
+		if err != nil {
+			return translateError(err)
+		}
+		filter.Category = parsed
+	}
+	if decade := c.QueryParam("decade"); decade != "" {
+		parsed, err := strconv.Atoi(decade)
+		if err != nil {
+			return translateError(domain.ErrInvalidInput)
+		}
+		filter.Decade = parsed
 	}
 
+	stats, err := h.prizeService.GetStats(c.Request().Context(), filter)
 	if err != nil {
 		return translateError(err)
 	}
 
-	return h.handlePage(c, RoutePrize, templates.Prize(prizes, categories, years, category, year))
+	return h.handlePage(c, RouteStats, templates.Stats(stats))
 }
 
 func translateError(err error) error {
 	if errors.Is(err, domain.ErrUserNotFound) {
 		return echo.NewHTTPError(http.StatusNotFound, "User not found")
 	}
+	if errors.Is(err, domain.PrizeNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Prize not found")
+	}
+	if errors.Is(err, domain.ErrLaureateNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Laureate not found")
+	}
+	if errors.Is(err, domain.ErrSessionNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Session not found")
+	}
+	if errors.Is(err, domain.ErrTwoFactorMissing) {
+		return echo.NewHTTPError(http.StatusConflict, "Two-factor authentication not set up")
+	}
+	if errors.Is(err, domain.ErrUsernameTaken) || errors.Is(err, domain.ErrEmailTaken) {
+		return echo.NewHTTPError(http.StatusConflict, "User already exists")
+	}
+	if errors.Is(err, domain.ErrLastAdmin) {
+		return echo.NewHTTPError(http.StatusBadRequest, "The last active administrator must remain")
+	}
 	if errors.Is(err, domain.ErrInvalidInput) {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid input")
 	}
@@ -191,25 +949,14 @@ func (h *Handler) handlePage(c echo.Context, page string, contents templ.Compone
 	var user *domain.User
 
 	// On vérifie d'abord si l'utilisateur est dans le contexte (cas du login/logout)
-	if u, ok := c.Get("user").(domain.User); ok {
+	if u, ok := c.Get(ContextUser).(domain.User); ok {
 		user = &u
-	} else if uPtr, ok := c.Get("user").(*domain.User); ok {
+	} else if uPtr, ok := c.Get(ContextUser).(*domain.User); ok {
 		user = uPtr
 	} else if c.Get("logout") == nil {
-		// Sinon on cherche dans le cookie, sauf si on vient de se déconnecter
-		if cookie, err := c.Cookie("session"); err == nil && cookie.Value != "" {
-			token, err := jwt.Parse(cookie.Value, func(token *jwt.Token) (interface{}, error) {
-				return []byte(h.config.JWTSecret), nil
-			})
-			if err == nil && token.Valid {
-				if claims, ok := token.Claims.(jwt.MapClaims); ok {
-					if sub, ok := claims["sub"].(string); ok {
-						if u, err := h.authService.GetUserByUsername(c.Request().Context(), sub); err == nil {
-							user = &u
-						}
-					}
-				}
-			}
+		// Sinon on cherche dans les cookies, sauf si on vient de se déconnecter
+		if u, _, err := Authenticate(c, h.authService); err == nil {
+			user = &u
 		}
 	}
 
@@ -234,3 +981,13 @@ func (h *Handler) handlePage(c echo.Context, page string, contents templ.Compone
 	}
 	return nil
 }
+
+// handleFragment renders a component on its own, without navigation or layout,
+// for htmx requests that target an element inside the current page.
+func (h *Handler) handleFragment(c echo.Context, contents templ.Component) error {
+	if err := contents.Render(c.Request().Context(), c.Response().Writer); err != nil {
+		slog.Error("Render error", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Erreur de rendu").SetInternal(err)
+	}
+	return nil
+}
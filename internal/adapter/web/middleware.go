@@ -0,0 +1,101 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"spahtmx/internal/app"
+	"spahtmx/internal/domain"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PageViewMiddleware records every page view under its route pattern (e.g.
+// "/prize/:id"), with how it was loaded, its status, latency and referrer
+// host. The visitor's address is never recorded. Static files, API calls
+// and htmx requests that only refresh part of a page are not page views.
+func PageViewMiddleware(pageViews *app.PageViewService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			if !isPageView(c) {
+				return err
+			}
+
+			pageViews.Record(domain.PageView{
+				Route:        c.Path(),
+				HTMX:         c.Request().Header.Get("HX-Request") == "true",
+				Status:       responseStatus(c, err),
+				Latency:      time.Since(start),
+				ReferrerHost: referrerHost(c.Request()),
+				At:           start,
+			})
+			return err
+		}
+	}
+}
+
+func isPageView(c echo.Context) bool {
+	req := c.Request()
+	if req.Method != http.MethodGet {
+		return false
+	}
+	path := c.Path()
+	if path == "" || path == RouteStatus || strings.HasPrefix(path, RouteStatic) || strings.HasPrefix(path, "/api/") {
+		return false
+	}
+	// Une navigation htmx remplace #content ; les autres cibles sont des fragments
+	if req.Header.Get("HX-Request") == "true" && req.Header.Get("HX-Target") != "content" {
+		return false
+	}
+	return true
+}
+
+// responseStatus is the status the request is answered with, including the
+// errors that Echo turns into a response after the middleware returns.
+func responseStatus(c echo.Context, err error) int {
+	if err == nil {
+		return c.Response().Status
+	}
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	return http.StatusInternalServerError
+}
+
+// referrerHost returns the host of the referring page, or "" when there is
+// none or it is the site itself.
+func referrerHost(req *http.Request) string {
+	ref, err := url.Parse(req.Referer())
+	if err != nil || ref.Host == req.Host {
+		return ""
+	}
+	return ref.Host
+}
+
+// ContextUser is the key under which AuthMiddleware stores the logged-in
+// user in the Echo context.
+const ContextUser = "user"
+
+// ContextSession is the key under which AuthMiddleware stores the session
+// of the request.
+const ContextSession = "session"
+
+// RequireRole refuses requests from users whose role does not include the
+// given role. It must run after AuthMiddleware, so the role is the user's
+// current one rather than the one recorded in the token.
+func RequireRole(role domain.Role) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user, ok := c.Get(ContextUser).(domain.User)
+			if !ok || !user.Role.Includes(role) {
+				return echo.NewHTTPError(http.StatusForbidden, "Forbidden")
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package web
+
+import (
+	"net/http"
+	"spahtmx/internal/app"
+	"spahtmx/internal/domain"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// accessCookie holds the short-lived access token.
+	accessCookie = "session"
+	// refreshCookie holds the refresh token that renews the access token.
+	refreshCookie = "refresh"
+)
+
+// Authenticate returns the user and session of the request. When the access
+// token is missing or has expired, it renews the tokens with the refresh
+// token and sends the new ones back, so active users stay logged in.
+func Authenticate(c echo.Context, authService *app.AuthService) (domain.User, domain.Session, error) {
+	ctx := c.Request().Context()
+
+	err := app.ErrUnauthorized
+	if cookie, cookieErr := c.Cookie(accessCookie); cookieErr == nil && cookie.Value != "" {
+		var user domain.User
+		var session domain.Session
+		user, session, err = authService.Authenticate(ctx, cookie.Value)
+		if err == nil {
+			return user, session, nil
+		}
+	}
+
+	cookie, cookieErr := c.Cookie(refreshCookie)
+	if cookieErr != nil || cookie.Value == "" {
+		return domain.User{}, domain.Session{}, err
+	}
+	user, session, refreshToken, err := authService.Refresh(ctx, cookie.Value)
+	if err != nil {
+		clearSessionCookies(c)
+		return domain.User{}, domain.Session{}, err
+	}
+	if err := setSessionCookies(c, authService, user, session, refreshToken); err != nil {
+		return domain.User{}, domain.Session{}, err
+	}
+	return user, session, nil
+}
+
+// setSessionCookies sends a new access token for the session and, unless it
+// is empty, the refresh token.
+func setSessionCookies(c echo.Context, authService *app.AuthService, user domain.User, session domain.Session, refreshToken string) error {
+	accessToken, expiresAt, err := authService.GenerateToken(user, session)
+	if err != nil {
+		return err
+	}
+	c.SetCookie(sessionCookie(accessCookie, accessToken, expiresAt))
+	if refreshToken != "" {
+		c.SetCookie(sessionCookie(refreshCookie, refreshToken, session.ExpiresAt))
+	}
+	return nil
+}
+
+// clearSessionCookies removes both session cookies from the browser.
+func clearSessionCookies(c echo.Context) {
+	for _, name := range []string{accessCookie, refreshCookie} {
+		cookie := sessionCookie(name, "", time.Time{})
+		cookie.MaxAge = -1
+		c.SetCookie(cookie)
+	}
+}
+
+func sessionCookie(name, value string, expires time.Time) *http.Cookie {
+	cookie := new(http.Cookie)
+	cookie.Name = name
+	cookie.Value = value
+	cookie.Path = "/"
+	cookie.Expires = expires
+	cookie.HttpOnly = true
+	cookie.SameSite = http.SameSiteLaxMode
+	return cookie
+}
@@ -0,0 +1,296 @@
+package templates
+
+import (
+	"fmt"
+	"spahtmx/internal/domain"
+	"strconv"
+)
+
+// Charts are drawn as plain SVG so the statistics page needs no JavaScript.
+// The helpers below turn series into shapes with precomputed coordinates.
+
+const (
+	chartWidth     = 640
+	chartHeight    = 260
+	chartPadLeft   = 40
+	chartPadRight  = 8
+	chartPadTop    = 12
+	chartPadBottom = 28
+
+	hbarRowHeight  = 26
+	hbarLabelWidth = 220
+	hbarValueWidth = 48
+)
+
+var categoryColors = map[domain.Category]string{
+	domain.CategoryChemistry:  "#0ea5e9",
+	domain.CategoryEconomics:  "#f59e0b",
+	domain.CategoryLiterature: "#8b5cf6",
+	domain.CategoryMedicine:   "#10b981",
+	domain.CategoryPeace:      "#ef4444",
+	domain.CategoryPhysics:    "#667eea",
+}
+
+const (
+	soloColor   = "#667eea"
+	sharedColor = "#764ba2"
+)
+
+// chartSeries names one stacked series of a bar chart, for the legend.
+type chartSeries struct {
+	Label string
+	Color string
+}
+
+// chartBar is one stacked bar; Values follow the chart's series.
+type chartBar struct {
+	Label  string
+	Values []int
+}
+
+// hbar is one row of a horizontal bar chart.
+type hbar struct {
+	Label string
+	Value float64
+	Text  string
+	Href  string
+}
+
+type svgRect struct {
+	X, Y, W, H string
+	Color      string
+	Title      string
+}
+
+type svgText struct {
+	X, Y string
+	Text string
+}
+
+func coord(v float64) string {
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
+
+// niceCeil rounds a positive maximum up to 1, 2 or 5 times a power of ten so
+// the axis ticks fall on round numbers.
+func niceCeil(v int) int {
+	if v <= 0 {
+		return 1
+	}
+	step := 1
+	for {
+		for _, m := range []int{1, 2, 5} {
+			if m*step >= v {
+				return m * step
+			}
+		}
+		step *= 10
+	}
+}
+
+func barsMax(bars []chartBar) int {
+	top := 0
+	for _, b := range bars {
+		total := 0
+		for _, v := range b.Values {
+			total += v
+		}
+		top = max(top, total)
+	}
+	return niceCeil(top)
+}
+
+// stackedRects lays out the segments of each bar, bottom to top in series
+// order.
+func stackedRects(bars []chartBar, series []chartSeries) []svgRect {
+	if len(bars) == 0 {
+		return nil
+	}
+	plotW := float64(chartWidth - chartPadLeft - chartPadRight)
+	plotH := float64(chartHeight - chartPadTop - chartPadBottom)
+	slot := plotW / float64(len(bars))
+	scale := plotH / float64(barsMax(bars))
+	barW := min(slot*0.7, 48)
+
+	var rects []svgRect
+	for i, b := range bars {
+		x := chartPadLeft + (float64(i)+0.5)*slot - barW/2
+		y := float64(chartHeight - chartPadBottom)
+		for j, v := range b.Values {
+			if v == 0 {
+				continue
+			}
+			h := float64(v) * scale
+			y -= h
+			rects = append(rects, svgRect{
+				X: coord(x), Y: coord(y), W: coord(barW), H: coord(h),
+				Color: series[j].Color,
+				Title: fmt.Sprintf("%s · %s : %d", b.Label, series[j].Label, v),
+			})
+		}
+	}
+	return rects
+}
+
+// barLabels places each bar's label under it, skipping some when the bars
+// are too narrow for every label to fit.
+func barLabels(bars []chartBar) []svgText {
+	if len(bars) == 0 {
+		return nil
+	}
+	slot := float64(chartWidth-chartPadLeft-chartPadRight) / float64(len(bars))
+	every := 1
+	for slot*float64(every) < 36 {
+		every++
+	}
+	var labels []svgText
+	for i, b := range bars {
+		if i%every != 0 {
+			continue
+		}
+		labels = append(labels, svgText{
+			X:    coord(chartPadLeft + (float64(i)+0.5)*slot),
+			Y:    coord(chartHeight - chartPadBottom + 16),
+			Text: b.Label,
+		})
+	}
+	return labels
+}
+
+// axisTicks returns the horizontal grid lines at 0, half and the maximum.
+func axisTicks(bars []chartBar) []svgText {
+	top := barsMax(bars)
+	plotH := float64(chartHeight - chartPadTop - chartPadBottom)
+	var ticks []svgText
+	for _, v := range []int{0, top / 2, top} {
+		ticks = append(ticks, svgText{
+			X:    coord(chartPadLeft - 6),
+			Y:    coord(float64(chartHeight-chartPadBottom) - float64(v)/float64(top)*plotH),
+			Text: strconv.Itoa(v),
+		})
+	}
+	return ticks
+}
+
+func hbarHeight(rows []hbar) string {
+	return strconv.Itoa(len(rows)*hbarRowHeight + 4)
+}
+
+func hbarWidth(row hbar, rows []hbar) string {
+	top := 0.0
+	for _, r := range rows {
+		top = max(top, r.Value)
+	}
+	if top == 0 {
+		return "0"
+	}
+	return coord(row.Value / top * float64(chartWidth-hbarLabelWidth-hbarValueWidth))
+}
+
+func hbarY(i int) string {
+	return strconv.Itoa(i*hbarRowHeight + 4)
+}
+
+func hbarTextY(i int) string {
+	return strconv.Itoa(i*hbarRowHeight + 4 + hbarRowHeight/2)
+}
+
+func categorySeries() []chartSeries {
+	series := make([]chartSeries, 0, len(domain.Categories))
+	for _, c := range domain.Categories {
+		series = append(series, chartSeries{Label: string(c), Color: categoryColors[c]})
+	}
+	return series
+}
+
+// decadeBars stacks the prize counts of each decade by category.
+func decadeBars(counts []domain.CategoryDecadeCount) []chartBar {
+	var bars []chartBar
+	index := map[int]int{}
+	for _, c := range counts {
+		i, ok := index[c.Decade]
+		if !ok {
+			i = len(bars)
+			index[c.Decade] = i
+			bars = append(bars, chartBar{Label: strconv.Itoa(c.Decade), Values: make([]int, len(domain.Categories))})
+		}
+		for j, cat := range domain.Categories {
+			if cat == c.Category {
+				bars[i].Values[j] += c.Count
+			}
+		}
+	}
+	return bars
+}
+
+func sharingSeries() []chartSeries {
+	return []chartSeries{{Label: "Un seul lauréat", Color: soloColor}, {Label: "Partagés", Color: sharedColor}}
+}
+
+func sharingBars(counts []domain.SharingCount) []chartBar {
+	bars := make([]chartBar, 0, len(counts))
+	for _, c := range counts {
+		bars = append(bars, chartBar{Label: strconv.Itoa(c.Decade), Values: []int{c.Solo, c.Shared}})
+	}
+	return bars
+}
+
+func multipleLaureateBars(counts []domain.LaureateCount) []hbar {
+	rows := make([]hbar, 0, len(counts))
+	for _, c := range counts {
+		rows = append(rows, hbar{
+			Label: c.Laureate.Name(),
+			Value: float64(c.Prizes),
+			Text:  strconv.Itoa(c.Prizes),
+			Href:  "/laureate/" + c.Laureate.ID,
+		})
+	}
+	return rows
+}
+
+func averageBars(averages []domain.CategoryAverage) []hbar {
+	rows := make([]hbar, 0, len(averages))
+	for _, a := range averages {
+		rows = append(rows, hbar{
+			Label: string(a.Category),
+			Value: a.Average(),
+			Text:  strconv.FormatFloat(a.Average(), 'f', 2, 64),
+		})
+	}
+	return rows
+}
+
+func loadSeries() []chartSeries {
+	return []chartSeries{{Label: "Chargement complet", Color: soloColor}, {Label: "Navigation htmx", Color: sharedColor}}
+}
+
+func trendBars(days []domain.DayViews) []chartBar {
+	bars := make([]chartBar, 0, len(days))
+	for _, d := range days {
+		bars = append(bars, chartBar{Label: d.Day.Format("02/01"), Values: []int{d.Full, d.HTMX}})
+	}
+	return bars
+}
+
+func referrerBars(referrers []domain.ReferrerViews) []hbar {
+	rows := make([]hbar, 0, len(referrers))
+	for _, r := range referrers {
+		label := r.Host
+		switch label {
+		case "":
+			label = "Direct ou interne"
+		case domain.OtherReferrerHost:
+			label = "Autres sites"
+		}
+		rows = append(rows, hbar{Label: label, Value: float64(r.Views), Text: strconv.Itoa(r.Views)})
+	}
+	return rows
+}
+
+func latencyBars(latency []domain.LatencyViews) []hbar {
+	rows := make([]hbar, 0, len(latency))
+	for _, l := range latency {
+		rows = append(rows, hbar{Label: string(l.Bucket), Value: float64(l.Views), Text: strconv.Itoa(l.Views)})
+	}
+	return rows
+}
@@ -0,0 +1,70 @@
+package templates
+
+import (
+	"context"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/a-h/templ"
+)
+
+// highlight renders text with every word starting with one of the search
+// terms wrapped in a <mark>. The terms are expected in lower case, as
+// returned by domain.SearchTerms.
+func highlight(text string, terms []string) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		if len(terms) == 0 {
+			_, err := io.WriteString(w, templ.EscapeString(text))
+			return err
+		}
+
+		var b strings.Builder
+		plain := 0
+		wordStart := true
+		for i, r := range text {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				wordStart = true
+				continue
+			}
+			if !wordStart || i < plain {
+				continue
+			}
+			wordStart = false
+			if n := matchPrefix(text[i:], terms); n > 0 {
+				b.WriteString(templ.EscapeString(text[plain:i]))
+				b.WriteString(`<mark class="bg-yellow-200 rounded px-0.5">`)
+				b.WriteString(templ.EscapeString(text[i : i+n]))
+				b.WriteString(`</mark>`)
+				plain = i + n
+			}
+		}
+		b.WriteString(templ.EscapeString(text[plain:]))
+
+		_, err := io.WriteString(w, b.String())
+		return err
+	})
+}
+
+// matchPrefix returns the byte length of the longest term that s starts
+// with, compared case-insensitively, or 0 when none matches.
+func matchPrefix(s string, terms []string) int {
+	best := 0
+	for _, term := range terms {
+		i := 0
+		matched := true
+		for _, tr := range term {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if size == 0 || unicode.ToLower(r) != tr {
+				matched = false
+				break
+			}
+			i += size
+		}
+		if matched && i > best {
+			best = i
+		}
+	}
+	return best
+}
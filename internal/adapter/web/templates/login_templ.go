@@ -29,7 +29,7 @@ func Login(errorMsg string) templ.Component {
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<title>Connexion - SPA HTMX</title><div class=\"flex justify-center items-center py-12\"><div class=\"bg-white rounded-xl shadow-2xl p-8 max-w-md w-full animate-fade-in\"><h1 class=\"text-3xl font-bold text-primary mb-6 text-center\">Connexion</h1>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<title>Connexion - SPA HTMX</title><div class=\"flex justify-center items-center py-12\"><div id=\"login-card\" class=\"bg-white rounded-xl shadow-2xl p-8 max-w-md w-full animate-fade-in\"><h1 class=\"text-3xl font-bold text-primary mb-6 text-center\">Connexion</h1>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -52,7 +52,7 @@ func Login(errorMsg string) templ.Component {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "<form hx-post=\"/login\" hx-target=\"#content\" hx-push-url=\"true\" class=\"space-y-6\"><div><label for=\"username\" class=\"block text-sm font-medium text-gray-700 mb-1\">Nom d'utilisateur</label> <input type=\"text\" id=\"username\" name=\"username\" required class=\"w-full px-4 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-primary focus:border-transparent outline-none transition-all\"></div><div><label for=\"password\" class=\"block text-sm font-medium text-gray-700 mb-1\">Mot de passe</label> <input type=\"password\" id=\"password\" name=\"password\" required class=\"w-full px-4 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-primary focus:border-transparent outline-none transition-all\"></div><button type=\"submit\" class=\"w-full bg-primary text-white font-bold py-3 rounded-lg hover:bg-secondary transition-colors duration-300 shadow-lg\">Se connecter</button></form></div></div><style>\n    @keyframes fade-in {\n        from { opacity: 0; transform: translateY(20px); }\n        to { opacity: 1; transform: translateY(0); }\n    }\n    .animate-fade-in {\n        animation: fade-in 0.3s ease-in;\n    }\n    </style>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "<form hx-post=\"/login\" hx-target=\"#content\" hx-push-url=\"true\" class=\"space-y-6\"><div><label for=\"username\" class=\"block text-sm font-medium text-gray-700 mb-1\">Nom d'utilisateur</label> <input type=\"text\" id=\"username\" name=\"username\" required class=\"w-full px-4 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-primary focus:border-transparent outline-none transition-all\"></div><div><label for=\"password\" class=\"block text-sm font-medium text-gray-700 mb-1\">Mot de passe</label> <input type=\"password\" id=\"password\" name=\"password\" required class=\"w-full px-4 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-primary focus:border-transparent outline-none transition-all\"></div><button type=\"submit\" class=\"w-full bg-primary text-white font-bold py-3 rounded-lg hover:bg-secondary transition-colors duration-300 shadow-lg\">Se connecter</button></form><p class=\"text-sm text-center mt-4\"><a href=\"/forgot-password\" hx-get=\"/forgot-password\" hx-target=\"#content\" hx-push-url=\"/forgot-password\" class=\"text-secondary hover:text-primary hover:underline\">Mot de passe oublié ?</a></p><p class=\"text-sm text-gray-500 text-center mt-6\">Pas encore de compte ? <a href=\"/register\" hx-get=\"/register\" hx-target=\"#content\" hx-push-url=\"/register\" class=\"text-primary font-semibold hover:underline\">Créer un compte</a></p></div></div><style>\n    @keyframes fade-in {\n        from { opacity: 0; transform: translateY(20px); }\n        to { opacity: 1; transform: translateY(0); }\n    }\n    .animate-fade-in {\n        animation: fade-in 0.3s ease-in;\n    }\n    </style>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
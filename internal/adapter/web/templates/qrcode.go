@@ -0,0 +1,45 @@
+package templates
+
+import (
+	"strconv"
+	"strings"
+
+	"rsc.io/qr"
+)
+
+// QR codes are drawn as SVG on the server, like the charts, so that the
+// secret they hold is never sent to a third-party image service.
+
+// qrQuietZone is the blank margin, in modules, that scanners need around
+// the code.
+const qrQuietZone = 4
+
+// qrImage is a QR code as a single SVG path of its dark modules.
+type qrImage struct {
+	ViewBox string
+	Path    string
+}
+
+// newQRImage encodes text as a QR code. It returns a zero qrImage when the
+// text is too long, in which case only the text itself can be shown.
+func newQRImage(text string) qrImage {
+	code, err := qr.Encode(text, qr.M)
+	if err != nil {
+		return qrImage{}
+	}
+
+	var path strings.Builder
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if !code.Black(x, y) {
+				continue
+			}
+			path.WriteString("M" + strconv.Itoa(x+qrQuietZone) + " " + strconv.Itoa(y+qrQuietZone) + "h1v1h-1z")
+		}
+	}
+	size := strconv.Itoa(code.Size + 2*qrQuietZone)
+	return qrImage{
+		ViewBox: "0 0 " + size + " " + size,
+		Path:    path.String(),
+	}
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"context"
+	"spahtmx/internal/domain"
+	"time"
+)
+
+// DefaultAuditEntries is how many audit entries the admin panel shows.
+const DefaultAuditEntries = 20
+
+type AuditService struct {
+	repo domain.AuditRepository
+}
+
+func NewAuditService(repo domain.AuditRepository) *AuditService {
+	return &AuditService{
+		repo: repo,
+	}
+}
+
+// Record adds an entry to the audit log. An empty actor stands for the
+// application itself.
+func (s *AuditService) Record(ctx context.Context, actor string, action domain.AuditAction, target, detail string) error {
+	return s.repo.AddAuditEntry(ctx, domain.AuditEntry{
+		At:     time.Now(),
+		Actor:  actor,
+		Action: action,
+		Target: target,
+		Detail: detail,
+	})
+}
+
+// GetRecent returns the latest audit entries, most recent first.
+func (s *AuditService) GetRecent(ctx context.Context) ([]domain.AuditEntry, error) {
+	return s.repo.GetAuditEntries(ctx, DefaultAuditEntries)
+}
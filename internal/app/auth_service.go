@@ -2,8 +2,13 @@ package app
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"log/slog"
 	"spahtmx/internal/domain"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -14,44 +19,490 @@ var (
 	ErrUnauthorized = errors.New("unauthorized")
 )
 
+// SessionSettings configures how long sessions and their tokens last.
+type SessionSettings struct {
+	// AccessTokenTTL is how long an access token is accepted before it has
+	// to be renewed with the refresh token.
+	AccessTokenTTL time.Duration
+	// RefreshTokenTTL is how long a session lasts without being used. Each
+	// renewal pushes the expiry back, so active users stay logged in.
+	RefreshTokenTTL time.Duration
+	// RefreshReuseInterval is how long a refresh token is still accepted
+	// after it was exchanged, for concurrent requests sent before the browser
+	// received its replacement. Past it, reuse ends the session.
+	RefreshReuseInterval time.Duration
+}
+
+// loginChallengeTTL is how long a user has to enter a two-factor code
+// after giving the right password.
+const loginChallengeTTL = 5 * time.Minute
+
+// loginChallengePurpose is the purpose of the token that carries a login
+// from the password step to the code step.
+const loginChallengePurpose = "login-2fa"
+
+// LoginResult is the outcome of a login step. When Challenge is set, the
+// login is not complete: the user must send a two-factor code along with
+// the challenge to LoginSecondFactor. Enrollment is then set when the
+// user's role requires an authenticator app the user has not set up yet.
+type LoginResult struct {
+	User       domain.User
+	Challenge  string
+	Enrollment *TwoFactorEnrollment
+	// RecoveryCodes are the recovery codes of an enrolment completed while
+	// logging in, to be shown to the user.
+	RecoveryCodes []string
+}
+
+// Complete reports whether the user is logged in.
+func (r LoginResult) Complete() bool {
+	return r.Challenge == ""
+}
+
+// sessionTouchInterval limits how often the last use of a session is
+// written, so that a burst of requests does not write once per request.
+const sessionTouchInterval = time.Minute
+
 type AuthService struct {
-	userRepo domain.UserRepository
+	userRepo    domain.UserRepository
+	sessionRepo domain.SessionRepository
+	tokens      *TokenService
+	lockout     *LockoutService
+	twoFactor   *TwoFactorService
+	settings    SessionSettings
 }
 
-func NewAuthService(userRepo domain.UserRepository) *AuthService {
+func NewAuthService(userRepo domain.UserRepository, sessionRepo domain.SessionRepository, tokens *TokenService, lockout *LockoutService, twoFactor *TwoFactorService, settings SessionSettings) *AuthService {
 	return &AuthService{
-		userRepo: userRepo,
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		tokens:      tokens,
+		lockout:     lockout,
+		twoFactor:   twoFactor,
+		settings:    settings,
 	}
 }
 
-func (s *AuthService) Login(ctx context.Context, username, password string) (domain.User, error) {
+// Login checks the credentials of a user logging in from the given client
+// address. Repeated failures block further attempts for a while, reported
+// as a *domain.LoginBlockedError, and eventually lock the account; a
+// locked account is refused before its password is checked. Users
+// with two-factor authentication, or whose role requires it, get a
+// challenge to complete with LoginSecondFactor.
+func (s *AuthService) Login(ctx context.Context, username, password, address string) (LoginResult, error) {
+	if err := s.lockout.Check(ctx, username, address); err != nil {
+		return LoginResult{}, err
+	}
+
 	user, err := s.userRepo.GetByUsername(ctx, username)
 	if err != nil {
 		if errors.Is(err, domain.ErrUserNotFound) {
-			return domain.User{}, ErrUnauthorized
+			return LoginResult{}, s.loginFailed(ctx, username, address)
 		}
-		return domain.User{}, err
+		return LoginResult{}, err
+	}
+
+	// Un compte verrouillé est refusé quel que soit le mot de passe, sans
+	// quoi la réponse confirmerait le mot de passe deviné
+	if user.Status == domain.StatusLocked && !user.LockExpired(time.Now()) {
+		return LoginResult{}, user.CheckActive()
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 	if err != nil {
-		return domain.User{}, ErrUnauthorized
+		return LoginResult{}, s.loginFailed(ctx, username, address)
+	}
+
+	// Les autres statuts ne sont révélés qu'à qui connaît le mot de passe
+	if err := user.CheckActive(); err != nil {
+		return LoginResult{}, err
+	}
+
+	status, err := s.twoFactor.GetStatus(ctx, user)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	if status.Enabled || status.Required {
+		// Les échecs ne sont oubliés qu'une fois le code accepté, sans quoi
+		// le mot de passe permettrait de deviner le code indéfiniment
+		return s.loginChallenge(ctx, user, status)
+	}
+	if err := s.lockout.Succeed(ctx, user); err != nil {
+		return LoginResult{}, err
+	}
+	return LoginResult{User: user}, nil
+}
+
+// loginChallenge returns the result of a login waiting for a two-factor
+// code, with the enrolment to complete when the user has no app yet.
+func (s *AuthService) loginChallenge(ctx context.Context, user domain.User, status TwoFactorStatus) (LoginResult, error) {
+	now := time.Now()
+	challenge, err := s.tokens.SignFor(loginChallengePurpose, Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.Username,
+			ExpiresAt: jwt.NewNumericDate(now.Add(loginChallengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
+	if err != nil {
+		return LoginResult{}, err
+	}
+	return s.challengeResult(ctx, user, status, challenge)
+}
+
+// challengeResult returns the result of a login waiting for a code with the
+// given challenge.
+func (s *AuthService) challengeResult(ctx context.Context, user domain.User, status TwoFactorStatus, challenge string) (LoginResult, error) {
+	result := LoginResult{User: user, Challenge: challenge}
+	if !status.Enabled {
+		enrollment, err := s.twoFactor.Enroll(ctx, user)
+		if err != nil {
+			return LoginResult{}, err
+		}
+		result.Enrollment = &enrollment
+	}
+	return result, nil
+}
+
+// LoginSecondFactor completes a login with the challenge returned by Login
+// and a code from the authenticator app or a recovery code. When the login
+// enrols an app, the code confirms it and the result holds the new
+// recovery codes. A wrong code counts as a failed login and is reported as
+// domain.ErrInvalidCode, with a result still holding the challenge so that
+// the user can try again. An expired challenge is ErrUnauthorized.
+func (s *AuthService) LoginSecondFactor(ctx context.Context, challenge, code, address string) (LoginResult, error) {
+	claims, err := s.tokens.ParseFor(loginChallengePurpose, challenge)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	username := claims.Subject
+	if err := s.lockout.Check(ctx, username, address); err != nil {
+		return LoginResult{}, err
+	}
+
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return LoginResult{}, ErrUnauthorized
+		}
+		return LoginResult{}, err
+	}
+	if err := user.CheckActive(); err != nil {
+		return LoginResult{}, err
+	}
+
+	status, err := s.twoFactor.GetStatus(ctx, user)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	result := LoginResult{User: user}
+	switch {
+	case status.Enabled:
+		err = s.twoFactor.Verify(ctx, user, code)
+	case status.Required:
+		result.RecoveryCodes, err = s.twoFactor.Confirm(ctx, user, code)
+	}
+	if errors.Is(err, domain.ErrInvalidCode) {
+		if err := s.lockout.Fail(ctx, username, address); err != nil {
+			return LoginResult{}, err
+		}
+		retry, err := s.challengeResult(ctx, user, status, challenge)
+		if err != nil {
+			return LoginResult{}, err
+		}
+		return retry, domain.ErrInvalidCode
+	}
+	if errors.Is(err, domain.ErrTwoFactorMissing) {
+		// L'obligation a été ajoutée après la première étape
+		return LoginResult{}, ErrUnauthorized
+	}
+	if err != nil {
+		return LoginResult{}, err
+	}
+
+	if err := s.lockout.Succeed(ctx, user); err != nil {
+		return LoginResult{}, err
+	}
+	return result, nil
+}
+
+// loginFailed counts a failed login and returns the error to report.
+func (s *AuthService) loginFailed(ctx context.Context, username, address string) error {
+	if err := s.lockout.Fail(ctx, username, address); err != nil {
+		return err
+	}
+	return ErrUnauthorized
+}
+
+// Authenticate checks an access token on each request and returns its user
+// and session. It refuses tokens whose session was revoked or has expired,
+// and accounts that were disabled or locked since the token was issued.
+func (s *AuthService) Authenticate(ctx context.Context, tokenString string) (domain.User, domain.Session, error) {
+	claims, err := s.ParseToken(tokenString)
+	if err != nil {
+		return domain.User{}, domain.Session{}, err
+	}
+
+	session, err := s.sessionRepo.GetSession(ctx, claims.ID)
+	if err != nil {
+		if errors.Is(err, domain.ErrSessionNotFound) {
+			return domain.User{}, domain.Session{}, ErrUnauthorized
+		}
+		return domain.User{}, domain.Session{}, err
+	}
+	now := time.Now()
+	if session.Expired(now) {
+		return domain.User{}, domain.Session{}, ErrUnauthorized
+	}
+
+	user, err := s.userRepo.GetByUsername(ctx, claims.Subject)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return domain.User{}, domain.Session{}, ErrUnauthorized
+		}
+		return domain.User{}, domain.Session{}, err
+	}
+	if user.ID != session.UserID {
+		return domain.User{}, domain.Session{}, ErrUnauthorized
+	}
+	if err := user.CheckActive(); err != nil {
+		return domain.User{}, domain.Session{}, err
+	}
+
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		if err := s.sessionRepo.TouchSession(ctx, session.ID, now); err != nil {
+			return domain.User{}, domain.Session{}, err
+		}
+		session.LastSeenAt = now
+	}
+	session.Username = user.Username
+
+	return user, session, nil
+}
+
+// Refresh exchanges a refresh token for a new one and returns the user and
+// session it belongs to, with the session's expiry pushed back. The new
+// token is empty when the old one was already exchanged within the reuse
+// interval: the browser keeps the replacement it received then. Reusing a
+// token later ends the session, since it means the token was copied.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (domain.User, domain.Session, string, error) {
+	hash := hashToken(refreshToken)
+	token, err := s.sessionRepo.GetRefreshToken(ctx, hash)
+	if err != nil {
+		if errors.Is(err, domain.ErrRefreshNotFound) {
+			return domain.User{}, domain.Session{}, "", ErrUnauthorized
+		}
+		return domain.User{}, domain.Session{}, "", err
+	}
+	now := time.Now()
+	if token.Expired(now) {
+		return domain.User{}, domain.Session{}, "", ErrUnauthorized
+	}
+
+	rotate := !token.Used()
+	if token.Used() && now.Sub(token.UsedAt) > s.settings.RefreshReuseInterval {
+		slog.Warn("Refresh token reused, revoking session", "session", token.SessionID)
+		if err := s.RevokeSession(ctx, token.SessionID); err != nil {
+			return domain.User{}, domain.Session{}, "", err
+		}
+		return domain.User{}, domain.Session{}, "", ErrUnauthorized
+	}
+
+	session, err := s.sessionRepo.GetSession(ctx, token.SessionID)
+	if err != nil {
+		if errors.Is(err, domain.ErrSessionNotFound) {
+			return domain.User{}, domain.Session{}, "", ErrUnauthorized
+		}
+		return domain.User{}, domain.Session{}, "", err
+	}
+	if session.Expired(now) {
+		return domain.User{}, domain.Session{}, "", ErrUnauthorized
+	}
+
+	user, err := s.userRepo.GetUser(ctx, strconv.FormatInt(session.UserID, 10))
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return domain.User{}, domain.Session{}, "", ErrUnauthorized
+		}
+		return domain.User{}, domain.Session{}, "", err
+	}
+	if err := user.CheckActive(); err != nil {
+		return domain.User{}, domain.Session{}, "", err
+	}
+	session.Username = user.Username
+
+	if rotate {
+		// Une requête concurrente a pu échanger le jeton entre-temps
+		err := s.sessionRepo.UseRefreshToken(ctx, hash, now)
+		if errors.Is(err, domain.ErrRefreshUsed) {
+			rotate = false
+		} else if err != nil {
+			return domain.User{}, domain.Session{}, "", err
+		}
+	}
+	if !rotate {
+		return user, session, "", nil
+	}
+
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(s.settings.RefreshTokenTTL)
+	if err := s.sessionRepo.RenewSession(ctx, session.ID, session.LastSeenAt, session.ExpiresAt); err != nil {
+		return domain.User{}, domain.Session{}, "", err
+	}
+	newToken, err := s.issueRefreshToken(ctx, session, now)
+	if err != nil {
+		return domain.User{}, domain.Session{}, "", err
 	}
+	return user, session, newToken, nil
+}
 
-	return user, nil
+// Logout ends the session of the access token or, when it has expired, of
+// the refresh token. Tokens that match no session are ignored.
+func (s *AuthService) Logout(ctx context.Context, accessToken, refreshToken string) error {
+	if claims, err := s.ParseToken(accessToken); err == nil {
+		return s.RevokeSession(ctx, claims.ID)
+	}
+	if refreshToken == "" {
+		return nil
+	}
+	token, err := s.sessionRepo.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, domain.ErrRefreshNotFound) {
+			return nil
+		}
+		return err
+	}
+	return s.RevokeSession(ctx, token.SessionID)
+}
+
+// GetUserSessions returns the active sessions of a user.
+func (s *AuthService) GetUserSessions(ctx context.Context, userID int64) ([]domain.Session, error) {
+	return s.sessionRepo.GetUserSessions(ctx, userID, time.Now())
+}
+
+// GetActiveSessions returns the active sessions of every user.
+func (s *AuthService) GetActiveSessions(ctx context.Context) ([]domain.Session, error) {
+	return s.sessionRepo.GetActiveSessions(ctx, time.Now())
+}
+
+// GetSession returns a session by ID.
+func (s *AuthService) GetSession(ctx context.Context, id string) (domain.Session, error) {
+	if id == "" {
+		return domain.Session{}, domain.ErrInvalidInput
+	}
+	return s.sessionRepo.GetSession(ctx, id)
+}
+
+// RevokeSession logs a session out.
+func (s *AuthService) RevokeSession(ctx context.Context, id string) error {
+	err := s.sessionRepo.DeleteSession(ctx, id)
+	if errors.Is(err, domain.ErrSessionNotFound) {
+		return nil
+	}
+	return err
+}
+
+// RevokeAllSessions logs a user out of every device.
+func (s *AuthService) RevokeAllSessions(ctx context.Context, userID int64) error {
+	return s.sessionRepo.DeleteUserSessions(ctx, userID)
 }
 
 func (s *AuthService) GetUserByUsername(ctx context.Context, username string) (domain.User, error) {
 	return s.userRepo.GetByUsername(ctx, username)
 }
 
-func (s *AuthService) GenerateToken(username, secret string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Subject:   username,
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+// StartSession records a new session of the user on the device identified
+// by its user agent and returns it with its first refresh token.
+func (s *AuthService) StartSession(ctx context.Context, user domain.User, userAgent string) (domain.Session, string, error) {
+	id, err := randomToken()
+	if err != nil {
+		return domain.Session{}, "", err
+	}
+
+	now := time.Now()
+	session := domain.Session{
+		ID:         id,
+		UserID:     user.ID,
+		Username:   user.Username,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.settings.RefreshTokenTTL),
+	}
+	if err := s.sessionRepo.DeleteExpiredSessions(ctx, now); err != nil {
+		return domain.Session{}, "", err
+	}
+	if err := s.sessionRepo.CreateSession(ctx, session); err != nil {
+		return domain.Session{}, "", err
+	}
+	refreshToken, err := s.issueRefreshToken(ctx, session, now)
+	if err != nil {
+		return domain.Session{}, "", err
+	}
+	return session, refreshToken, nil
+}
+
+// issueRefreshToken stores a new refresh token of the session, valid until
+// the session expires, and returns it.
+func (s *AuthService) issueRefreshToken(ctx context.Context, session domain.Session, now time.Time) (string, error) {
+	refreshToken, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	err = s.sessionRepo.CreateRefreshToken(ctx, domain.RefreshToken{
+		Hash:      hashToken(refreshToken),
+		SessionID: session.ID,
+		CreatedAt: now,
+		ExpiresAt: session.ExpiresAt,
+	})
+	if err != nil {
+		return "", err
+	}
+	return refreshToken, nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken is what is stored of a refresh token, so that reading the
+// database does not give access to sessions.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateToken signs a short-lived access token for the session and
+// returns it with its expiry, which never goes past the session's.
+func (s *AuthService) GenerateToken(user domain.User, session domain.Session) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.settings.AccessTokenTTL)
+	if session.ExpiresAt.Before(expiresAt) {
+		expiresAt = session.ExpiresAt
+	}
+
+	signed, err := s.tokens.Sign(Claims{
+		Role: user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        session.ID,
+			Subject:   user.Username,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
 	})
-	return token.SignedString([]byte(secret))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// ParseToken verifies an access token and returns its claims.
+func (s *AuthService) ParseToken(tokenString string) (*Claims, error) {
+	return s.tokens.Parse(tokenString)
 }
 
 func (s *AuthService) HashPassword(password string) (string, error) {
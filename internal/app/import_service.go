@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"spahtmx/internal/domain"
+)
+
+// ImportIssue describes a problem found with one record of an import.
+type ImportIssue struct {
+	Index    int
+	Year     int
+	Category domain.Category
+	Err      error
+}
+
+// ImportReport summarises a prize import. Rejected records were not stored;
+// flagged ones were stored but have inconsistent laureate shares. Records
+// without laureates are stored as prizes not awarded.
+type ImportReport struct {
+	Loaded    int
+	Inserted  int
+	Laureates int
+	// Organisations counts the distinct laureates that are organisations.
+	Organisations int
+	NotAwarded    int
+	Rejected      []ImportIssue
+	Flagged       []ImportIssue
+}
+
+// singleNamePeople lists laureates of the Nobel dataset who are people known
+// by a single name, which would otherwise be taken for organisations.
+var singleNamePeople = map[string]bool{
+	"531": true, // Le Duc Tho
+	"553": true, // Aung San Suu Kyi
+}
+
+type ImportService struct {
+	repo domain.PrizeRepository
+}
+
+func NewImportService(r domain.PrizeRepository) *ImportService {
+	return &ImportService{
+		repo: r,
+	}
+}
+
+// ImportPrizes reads prizes in the Nobel dataset format ({"prizes": [...]})
+// and stores the valid ones. Each record is decoded on its own so that a
+// malformed one is reported instead of aborting the whole import.
+func (s *ImportService) ImportPrizes(ctx context.Context, r io.Reader) (ImportReport, error) {
+	var raw struct {
+		Prizes []json.RawMessage `json:"prizes"`
+	}
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return ImportReport{}, err
+	}
+
+	report := ImportReport{Loaded: len(raw.Prizes)}
+	laureates := map[string]domain.LaureateKind{}
+
+	for i, record := range raw.Prizes {
+		var p domain.Prize
+		if err := json.Unmarshal(record, &p); err != nil {
+			report.Rejected = append(report.Rejected, ImportIssue{Index: i, Err: err})
+			continue
+		}
+		issue := ImportIssue{Index: i, Year: p.Year, Category: p.Category}
+		inferLaureateKinds(&p)
+
+		if err := p.Validate(); err != nil {
+			issue.Err = err
+			report.Rejected = append(report.Rejected, issue)
+			continue
+		}
+
+		if notAwarded, ok := p.NotAwarded(); ok {
+			if err := s.repo.SaveNotAwarded(ctx, notAwarded); err != nil {
+				if errors.Is(err, context.Canceled) {
+					return report, err
+				}
+				issue.Err = err
+				report.Rejected = append(report.Rejected, issue)
+				continue
+			}
+			report.NotAwarded++
+			continue
+		}
+
+		if err := p.ValidateShares(); err != nil {
+			issue.Err = err
+			report.Flagged = append(report.Flagged, issue)
+		}
+
+		if err := s.repo.Save(ctx, p); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return report, err
+			}
+			issue.Err = err
+			report.Rejected = append(report.Rejected, issue)
+			continue
+		}
+
+		for _, l := range p.Laureates {
+			laureates[l.ID] = l.Kind
+		}
+		report.Inserted++
+	}
+
+	report.Laureates = len(laureates)
+	for _, kind := range laureates {
+		if kind == domain.KindOrganisation {
+			report.Organisations++
+		}
+	}
+	return report, nil
+}
+
+// inferLaureateKinds fills in the kind of laureates the record leaves unset.
+func inferLaureateKinds(p *domain.Prize) {
+	for i, l := range p.Laureates {
+		if l.Kind != "" {
+			continue
+		}
+		if singleNamePeople[l.ID] {
+			p.Laureates[i].Kind = domain.KindPerson
+		} else {
+			p.Laureates[i].Kind = domain.InferLaureateKind(l)
+		}
+	}
+}
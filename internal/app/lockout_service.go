@@ -0,0 +1,225 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"spahtmx/internal/domain"
+	"strconv"
+	"time"
+)
+
+// AccountLockFailures is how many failed logins in a row lock an account
+// for AccountLockDuration, unless an administrator unlocks it sooner.
+const AccountLockFailures = 10
+
+// AccountLockDuration is how long an account stays locked after
+// AccountLockFailures failed logins.
+const AccountLockDuration = time.Hour
+
+// throttleRule describes how failed logins are slowed down: after free
+// failures within window, each further failure blocks logins for twice as
+// long as the previous one, starting at base and up to max.
+type throttleRule struct {
+	free   int
+	base   time.Duration
+	max    time.Duration
+	window time.Duration
+}
+
+var (
+	usernameRule = throttleRule{free: 3, base: 30 * time.Second, max: 15 * time.Minute, window: 24 * time.Hour}
+	// Une adresse peut être partagée par plusieurs utilisateurs légitimes
+	addressRule = throttleRule{free: 20, base: time.Minute, max: time.Hour, window: time.Hour}
+)
+
+// delay returns how long logins are blocked after the given number of
+// failures.
+func (r throttleRule) delay(failures int) time.Duration {
+	if failures <= r.free {
+		return 0
+	}
+	d := r.base
+	for i := r.free + 1; i < failures && d < r.max; i++ {
+		d *= 2
+	}
+	return min(d, r.max)
+}
+
+// LockoutService protects logins against password guessing. It counts
+// failed logins per username and per client address, blocks further
+// attempts for exponentially longer periods and temporarily locks accounts
+// after AccountLockFailures failures. Its state is kept in the database, so a
+// restart does not reset it.
+type LockoutService struct {
+	repo     domain.LoginThrottleRepository
+	userRepo domain.UserRepository
+	audit    *AuditService
+}
+
+func NewLockoutService(repo domain.LoginThrottleRepository, userRepo domain.UserRepository, audit *AuditService) *LockoutService {
+	return &LockoutService{
+		repo:     repo,
+		userRepo: userRepo,
+		audit:    audit,
+	}
+}
+
+// Check returns a *domain.LoginBlockedError while logins for the username
+// or from the address are blocked.
+func (s *LockoutService) Check(ctx context.Context, username, address string) error {
+	throttles, err := s.repo.GetLoginThrottles(ctx, []string{
+		domain.UsernameThrottleKey(username),
+		domain.AddressThrottleKey(address),
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var until time.Time
+	for _, t := range throttles {
+		if t.Blocked(now) && t.BlockedUntil.After(until) {
+			until = t.BlockedUntil
+		}
+	}
+	if until.IsZero() {
+		return nil
+	}
+	return &domain.LoginBlockedError{Until: until}
+}
+
+// Fail counts a failed login for the username and the address.
+func (s *LockoutService) Fail(ctx context.Context, username, address string) error {
+	now := time.Now()
+
+	failures, err := s.addFailure(ctx, domain.UsernameThrottleKey(username), usernameRule, now)
+	if err != nil {
+		return err
+	}
+	if _, err := s.addFailure(ctx, domain.AddressThrottleKey(address), addressRule, now); err != nil {
+		return err
+	}
+
+	if failures >= AccountLockFailures {
+		return s.lockAccount(ctx, username, failures, now)
+	}
+	return nil
+}
+
+// addFailure counts a failure for the key, blocks it when the rule says so
+// and returns the number of recent failures.
+func (s *LockoutService) addFailure(ctx context.Context, key string, rule throttleRule, now time.Time) (int, error) {
+	throttle, err := s.repo.AddLoginFailure(ctx, key, now, now.Add(-rule.window))
+	if err != nil {
+		return 0, err
+	}
+
+	delay := rule.delay(throttle.Failures)
+	if delay == 0 {
+		return throttle.Failures, nil
+	}
+	if err := s.repo.BlockLogin(ctx, key, now.Add(delay)); err != nil {
+		return 0, err
+	}
+	detail := fmt.Sprintf("%d échecs, bloqué pendant %s", throttle.Failures, delay)
+	if err := s.audit.Record(ctx, "", domain.AuditLoginBlocked, key, detail); err != nil {
+		return 0, err
+	}
+	return throttle.Failures, nil
+}
+
+// lockAccount locks the active account of the username, if there is one,
+// for AccountLockDuration. The last active administrator is never locked,
+// so that someone is always left to unlock accounts; the exponential block
+// still slows down attempts on it.
+func (s *LockoutService) lockAccount(ctx context.Context, username string, failures int, now time.Time) error {
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !user.IsActive() {
+		return nil
+	}
+	if user.Role.Includes(domain.RoleAdmin) {
+		last, err := lastAdmin(ctx, s.userRepo, user)
+		if err != nil || last {
+			return err
+		}
+	}
+
+	// Le blocage du nom d'utilisateur est conservé : l'effacer offrirait de
+	// nouvelles tentatives au moment même du verrouillage
+	id := strconv.FormatInt(user.ID, 10)
+	until := now.Add(AccountLockDuration)
+	if err := s.userRepo.LockUser(ctx, id, domain.LockedAfterFailuresReason, until); err != nil {
+		return err
+	}
+	detail := fmt.Sprintf("%d échecs de connexion consécutifs, verrouillé jusqu'au %s", failures, until.Local().Format("02/01/2006 15:04"))
+	return s.audit.Record(ctx, "", domain.AuditAccountLocked, user.Username, detail)
+}
+
+// Succeed forgets the failed logins of the user after a successful login
+// and marks an account whose lock has expired as active again. Failures
+// from the address are kept, so that an attacker cannot reset them by
+// logging into an account of their own.
+func (s *LockoutService) Succeed(ctx context.Context, user domain.User) error {
+	if err := s.repo.ClearLoginThrottle(ctx, domain.UsernameThrottleKey(user.Username)); err != nil {
+		return err
+	}
+	if !user.LockExpired(time.Now()) {
+		return nil
+	}
+	id := strconv.FormatInt(user.ID, 10)
+	if err := s.userRepo.UpdateUserStatus(ctx, id, domain.StatusActive, ""); err != nil {
+		return err
+	}
+	return s.audit.Record(ctx, "", domain.AuditAccountUnlocked, user.Username, "Verrouillage expiré")
+}
+
+// GetBlocked returns the usernames and addresses whose logins are blocked.
+func (s *LockoutService) GetBlocked(ctx context.Context) ([]domain.LoginThrottle, error) {
+	return s.repo.GetBlockedLogins(ctx, time.Now())
+}
+
+// Unblock lets logins for a username or from an address through again.
+func (s *LockoutService) Unblock(ctx context.Context, actor, key string) error {
+	if key == "" {
+		return domain.ErrInvalidInput
+	}
+	if err := s.repo.ClearLoginThrottle(ctx, key); err != nil {
+		return err
+	}
+	return s.audit.Record(ctx, actor, domain.AuditLoginUnblocked, key, "")
+}
+
+// UnlockUser reactivates a locked account and forgets its failed logins.
+func (s *LockoutService) UnlockUser(ctx context.Context, actor, id string) (domain.User, error) {
+	if id == "" {
+		return domain.User{}, domain.ErrInvalidInput
+	}
+	user, err := s.userRepo.GetUser(ctx, id)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if user.Status != domain.StatusLocked {
+		return domain.User{}, fmt.Errorf("%w: user %s is not locked", domain.ErrInvalidInput, user.Username)
+	}
+
+	if err := s.userRepo.UpdateUserStatus(ctx, id, domain.StatusActive, ""); err != nil {
+		return domain.User{}, err
+	}
+	if err := s.repo.ClearLoginThrottle(ctx, domain.UsernameThrottleKey(user.Username)); err != nil {
+		return domain.User{}, err
+	}
+	if err := s.audit.Record(ctx, actor, domain.AuditAccountUnlocked, user.Username, ""); err != nil {
+		return domain.User{}, err
+	}
+
+	user.Status = domain.StatusActive
+	user.StatusReason = ""
+	return user, nil
+}
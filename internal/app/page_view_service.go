@@ -0,0 +1,216 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"spahtmx/internal/domain"
+	"sync"
+	"time"
+)
+
+// Limits of the analytics report.
+const (
+	DefaultReportDays = 30
+	MaxReportDays     = 365
+	reportTopRoutes   = 10
+	reportTopSources  = 10
+)
+
+// maxReferrerHosts bounds the distinct referrer hosts counted between two
+// flushes, since the Referer header is set by the client.
+const maxReferrerHosts = 100
+
+// PageViewService aggregates page views in memory and writes them to the
+// repository in batches, so recording a view costs a map increment. Views
+// are aggregated by day, route, load type, status, latency bucket and
+// referrer host. Past maxReferrerHosts distinct hosts between two flushes,
+// new hosts are counted as domain.OtherReferrerHost.
+type PageViewService struct {
+	repo domain.PageViewRepository
+
+	mu      sync.Mutex
+	pending map[pageViewKey]int
+	hosts   map[string]struct{}
+}
+
+type pageViewKey struct {
+	day          time.Time
+	route        string
+	htmx         bool
+	status       int
+	latency      domain.LatencyBucket
+	referrerHost string
+}
+
+func NewPageViewService(r domain.PageViewRepository) *PageViewService {
+	return &PageViewService{
+		repo:    r,
+		pending: map[pageViewKey]int{},
+		hosts:   map[string]struct{}{},
+	}
+}
+
+// Record counts one page view.
+func (s *PageViewService) Record(view domain.PageView) {
+	key := pageViewKey{
+		day:          domain.Day(view.At),
+		route:        view.Route,
+		htmx:         view.HTMX,
+		status:       view.Status,
+		latency:      domain.BucketLatency(view.Latency),
+		referrerHost: view.ReferrerHost,
+	}
+
+	s.mu.Lock()
+	key.referrerHost = s.referrerHost(key.referrerHost)
+	s.pending[key]++
+	s.mu.Unlock()
+}
+
+// referrerHost returns the host to count a view under, folding new hosts
+// into domain.OtherReferrerHost once the batch holds too many. The caller
+// holds the lock.
+func (s *PageViewService) referrerHost(host string) string {
+	if host == "" {
+		return host
+	}
+	if _, ok := s.hosts[host]; ok {
+		return host
+	}
+	if len(s.hosts) >= maxReferrerHosts {
+		return domain.OtherReferrerHost
+	}
+	s.hosts[host] = struct{}{}
+	return host
+}
+
+// Flush writes the pending counts. They are kept for the next flush if the
+// write fails.
+func (s *PageViewService) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = map[pageViewKey]int{}
+	s.hosts = map[string]struct{}{}
+	s.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	counts := make([]domain.PageViewCount, 0, len(pending))
+	for key, count := range pending {
+		counts = append(counts, domain.PageViewCount{
+			Day:          key.day,
+			Route:        key.route,
+			HTMX:         key.htmx,
+			Status:       key.status,
+			Latency:      key.latency,
+			ReferrerHost: key.referrerHost,
+			Count:        count,
+		})
+	}
+	if err := s.repo.AddPageViews(ctx, counts); err != nil {
+		s.mu.Lock()
+		for key, count := range pending {
+			s.pending[key] += count
+			if key.referrerHost != "" && key.referrerHost != domain.OtherReferrerHost {
+				s.hosts[key.referrerHost] = struct{}{}
+			}
+		}
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Run flushes the pending counts every interval until the context is done.
+// The caller flushes one last time on shutdown.
+func (s *PageViewService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Flush(ctx); err != nil {
+				slog.Error("Failed to flush page views", "error", err)
+			}
+		}
+	}
+}
+
+// GetTotals returns the page view totals, counting views not flushed yet.
+func (s *PageViewService) GetTotals(ctx context.Context) (domain.PageViewTotals, error) {
+	today := domain.Day(time.Now())
+	totals, err := s.repo.GetPageViewTotals(ctx, today)
+	if err != nil {
+		return domain.PageViewTotals{}, err
+	}
+
+	s.mu.Lock()
+	for key, count := range s.pending {
+		totals.Add(key.day, today, count)
+	}
+	s.mu.Unlock()
+
+	return totals, nil
+}
+
+// GetReport flushes the pending views and returns the analytics of the last
+// days, today included. The trend has one entry per day and the latency one
+// per bucket, even without views.
+func (s *PageViewService) GetReport(ctx context.Context, days int) (domain.PageViewReport, error) {
+	if days <= 0 {
+		days = DefaultReportDays
+	}
+	days = min(days, MaxReportDays)
+
+	if err := s.Flush(ctx); err != nil {
+		return domain.PageViewReport{}, err
+	}
+
+	today := domain.Day(time.Now())
+	since := today.AddDate(0, 0, 1-days)
+	report := domain.PageViewReport{Days: days}
+
+	var err error
+	if report.Totals, err = s.repo.GetPageViewTotals(ctx, today); err != nil {
+		return domain.PageViewReport{}, err
+	}
+	if report.TopRoutes, err = s.repo.GetTopRoutes(ctx, since, reportTopRoutes); err != nil {
+		return domain.PageViewReport{}, err
+	}
+	if report.Referrers, err = s.repo.GetTopReferrers(ctx, since, reportTopSources); err != nil {
+		return domain.PageViewReport{}, err
+	}
+
+	latency, err := s.repo.GetLatencyViews(ctx, since)
+	if err != nil {
+		return domain.PageViewReport{}, err
+	}
+	byBucket := make(map[domain.LatencyBucket]int, len(latency))
+	for _, l := range latency {
+		byBucket[l.Bucket] = l.Views
+	}
+	for _, bucket := range domain.LatencyBuckets {
+		report.Latency = append(report.Latency, domain.LatencyViews{Bucket: bucket, Views: byBucket[bucket]})
+	}
+
+	daily, err := s.repo.GetDailyViews(ctx, since)
+	if err != nil {
+		return domain.PageViewReport{}, err
+	}
+	byDay := make(map[time.Time]domain.DayViews, len(daily))
+	for _, d := range daily {
+		byDay[domain.Day(d.Day)] = d
+	}
+	for day := since; !day.After(today); day = day.AddDate(0, 0, 1) {
+		views := byDay[day]
+		views.Day = day
+		report.Trend = append(report.Trend, views)
+	}
+
+	return report, nil
+}
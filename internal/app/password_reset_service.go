@@ -0,0 +1,157 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"spahtmx/internal/domain"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PasswordResetTTL is how long a password reset link stays valid.
+const PasswordResetTTL = time.Hour
+
+// maxPasswordResets is how many reset emails a user can receive per
+// PasswordResetTTL, so that the form cannot be used to flood a mailbox.
+const maxPasswordResets = 3
+
+// mailTimeout bounds the sending of an email in the background.
+const mailTimeout = 30 * time.Second
+
+// PasswordResetService lets users who forgot their password choose a new
+// one through a single-use link sent to their email.
+type PasswordResetService struct {
+	userRepo  domain.UserRepository
+	resetRepo domain.PasswordResetRepository
+	auth      *AuthService
+	mailer    domain.Mailer
+	baseURL   string
+}
+
+func NewPasswordResetService(userRepo domain.UserRepository, resetRepo domain.PasswordResetRepository, auth *AuthService, mailer domain.Mailer, baseURL string) *PasswordResetService {
+	return &PasswordResetService{
+		userRepo:  userRepo,
+		resetRepo: resetRepo,
+		auth:      auth,
+		mailer:    mailer,
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// RequestReset emails a reset link to the active account with this email.
+// It succeeds whether or not there is one, and sends the email in the
+// background, so that neither its result nor its duration tells whether an
+// account exists.
+func (s *PasswordResetService) RequestReset(ctx context.Context, email string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if domain.ValidateEmail(email) != nil {
+		return nil
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !user.IsActive() {
+		return nil
+	}
+
+	now := time.Now()
+	if err := s.resetRepo.DeleteExpiredPasswordResets(ctx, now); err != nil {
+		return err
+	}
+	count, err := s.resetRepo.CountPasswordResets(ctx, user.ID, now.Add(-PasswordResetTTL))
+	if err != nil {
+		return err
+	}
+	if count >= maxPasswordResets {
+		return nil
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	err = s.resetRepo.CreatePasswordReset(ctx, domain.PasswordReset{
+		Hash:      hashToken(token),
+		UserID:    user.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(PasswordResetTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	go s.sendReset(context.WithoutCancel(ctx), user, token)
+	return nil
+}
+
+func (s *PasswordResetService) sendReset(ctx context.Context, user domain.User, token string) {
+	ctx, cancel := context.WithTimeout(ctx, mailTimeout)
+	defer cancel()
+
+	link := s.baseURL + "/reset-password?token=" + url.QueryEscape(token)
+	err := s.mailer.Send(ctx, domain.Email{
+		To:      user.Email,
+		Subject: "Réinitialisation de votre mot de passe",
+		Body: fmt.Sprintf("Bonjour %s,\n\n"+
+			"Pour choisir un nouveau mot de passe, ouvrez le lien suivant dans les %d minutes :\n\n%s\n\n"+
+			"Ce lien ne peut servir qu'une fois. Si vous n'avez rien demandé, ignorez ce message : votre mot de passe reste inchangé.\n",
+			user.Username, int(PasswordResetTTL.Minutes()), link),
+	})
+	if err != nil {
+		slog.Error("Failed to send password reset email", "user", user.Username, "error", err)
+	}
+}
+
+// ResetPassword sets a new password with a reset token, which can then no
+// longer be used, and logs the user out of every session.
+func (s *PasswordResetService) ResetPassword(ctx context.Context, token, password, confirm string) error {
+	hash := hashToken(token)
+	reset, err := s.resetRepo.GetPasswordReset(ctx, hash)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if !reset.Valid(now) {
+		return domain.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetUser(ctx, strconv.FormatInt(reset.UserID, 10))
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return domain.ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+
+	// Le jeton n'est consommé qu'une fois le mot de passe accepté
+	if password != confirm {
+		return domain.ErrPasswordMismatch
+	}
+	if err := domain.CheckPasswordStrength(password, user.Username, user.Email); err != nil {
+		return err
+	}
+	if err := s.resetRepo.UsePasswordReset(ctx, hash, now); err != nil {
+		return err
+	}
+
+	user.Password, err = s.auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		return err
+	}
+	if err := s.resetRepo.DeleteUserPasswordResets(ctx, user.ID); err != nil {
+		return err
+	}
+	return s.auth.RevokeAllSessions(ctx, user.ID)
+}
@@ -15,30 +15,107 @@ func NewPrizeService(r domain.PrizeRepository) *PrizeService {
 	}
 }
 
-func (s *PrizeService) GetPrizes(ctx context.Context) ([]domain.Prize, error) {
-	return s.repo.GetPrizes(ctx)
-}
-
 func (s *PrizeService) GetPrize(ctx context.Context, id string) (domain.Prize, error) {
 	return s.repo.GetPrize(ctx, id)
 }
 
-func (s *PrizeService) GetPrizesByYear(ctx context.Context, year string) ([]domain.Prize, error) {
-	return s.repo.GetPrizesByYear(ctx, year)
+// neighbourCount is how many prizes of the same category are shown on each
+// side of a prize on its detail page.
+const neighbourCount = 2
+
+func (s *PrizeService) GetPrizeDetail(ctx context.Context, id string) (domain.PrizeDetail, error) {
+	prize, err := s.repo.GetPrize(ctx, id)
+	if err != nil {
+		return domain.PrizeDetail{}, err
+	}
+
+	neighbours, err := s.repo.GetNeighbourPrizes(ctx, prize.Category, prize.Year, neighbourCount)
+	if err != nil {
+		return domain.PrizeDetail{}, err
+	}
+
+	return domain.PrizeDetail{Prize: prize, Neighbours: neighbours}, nil
 }
 
-func (s *PrizeService) GetPrizesByCategory(ctx context.Context, category string) ([]domain.Prize, error) {
-	return s.repo.GetPrizesByCategory(ctx, category)
+// Page sizes accepted for prize listings.
+const (
+	DefaultPageSize = 12
+	MaxPageSize     = 60
+)
+
+func pageSize(size int) int {
+	if size <= 0 {
+		return DefaultPageSize
+	}
+	return min(size, MaxPageSize)
 }
 
-func (s *PrizeService) GetPrizesByCategoryAndYear(ctx context.Context, category string, year string) ([]domain.Prize, error) {
-	return s.repo.GetPrizesByCategoryAndYear(ctx, category, year)
+// GetPrizePage returns the page of prizes matching the criteria that follows
+// the cursor. When a category or year filter matches no prize, the page
+// lists the prizes that were not awarded instead.
+func (s *PrizeService) GetPrizePage(ctx context.Context, criteria domain.PrizeCriteria, after domain.PrizeCursor, size int) (domain.PrizePage, error) {
+	if err := criteria.Validate(); err != nil {
+		return domain.PrizePage{}, err
+	}
+	page, err := s.repo.GetPrizePage(ctx, criteria, after, pageSize(size))
+	if err != nil {
+		return domain.PrizePage{}, err
+	}
+
+	if len(page.Prizes) == 0 && after.IsZero() && criteria.OnlyCategoriesAndYears() {
+		page.NotAwarded, err = s.repo.GetNotAwarded(ctx, criteria)
+		if err != nil {
+			return domain.PrizePage{}, err
+		}
+	}
+	return page, nil
 }
 
-func (s *PrizeService) GetCategories(ctx context.Context) ([]string, error) {
+func (s *PrizeService) GetCategories(ctx context.Context) ([]domain.Category, error) {
 	return s.repo.GetCategories(ctx)
 }
 
-func (s *PrizeService) GetYears(ctx context.Context) ([]string, error) {
+func (s *PrizeService) GetYears(ctx context.Context) ([]int, error) {
 	return s.repo.GetYears(ctx)
 }
+
+func (s *PrizeService) GetLaureate(ctx context.Context, id string) (domain.LaureateProfile, error) {
+	if id == "" {
+		return domain.LaureateProfile{}, domain.ErrInvalidInput
+	}
+	return s.repo.GetLaureate(ctx, id)
+}
+
+// GetStats computes the statistics page aggregates for the filter.
+func (s *PrizeService) GetStats(ctx context.Context, filter domain.StatsFilter) (domain.PrizeStats, error) {
+	if err := filter.Validate(); err != nil {
+		return domain.PrizeStats{}, err
+	}
+
+	years, err := s.repo.GetYears(ctx)
+	if err != nil {
+		return domain.PrizeStats{}, err
+	}
+	stats := domain.PrizeStats{Filter: filter}
+	for _, year := range years {
+		decade := domain.Decade(year)
+		if n := len(stats.Decades); n == 0 || stats.Decades[n-1] != decade {
+			stats.Decades = append(stats.Decades, decade)
+		}
+	}
+
+	if stats.PerCategoryDecade, err = s.repo.GetPrizesPerCategoryDecade(ctx, filter); err != nil {
+		return domain.PrizeStats{}, err
+	}
+	if stats.SharingPerDecade, err = s.repo.GetSharingPerDecade(ctx, filter); err != nil {
+		return domain.PrizeStats{}, err
+	}
+	if stats.MultipleLaureates, err = s.repo.GetMultipleLaureates(ctx, filter); err != nil {
+		return domain.PrizeStats{}, err
+	}
+	if stats.AveragePerCategory, err = s.repo.GetAverageLaureates(ctx, filter); err != nil {
+		return domain.PrizeStats{}, err
+	}
+
+	return stats, nil
+}
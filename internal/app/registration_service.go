@@ -0,0 +1,141 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"spahtmx/internal/domain"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// EmailVerificationTTL is how long an email verification link stays valid.
+const EmailVerificationTTL = 24 * time.Hour
+
+const purposeVerifyEmail = "verify-email"
+
+// RegistrationInput is what a visitor fills in to create an account.
+type RegistrationInput struct {
+	Username        string
+	Email           string
+	Password        string
+	PasswordConfirm string
+}
+
+// RegistrationService lets visitors create their own account. New accounts
+// stay pending until their owner follows the signed link emailed to them.
+type RegistrationService struct {
+	userRepo domain.UserRepository
+	users    *UserService
+	tokens   *TokenService
+	mailer   domain.Mailer
+	baseURL  string
+}
+
+func NewRegistrationService(userRepo domain.UserRepository, users *UserService, tokens *TokenService, mailer domain.Mailer, baseURL string) *RegistrationService {
+	return &RegistrationService{
+		userRepo: userRepo,
+		users:    users,
+		tokens:   tokens,
+		mailer:   mailer,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Register creates a pending viewer account and emails its verification
+// link. The account is removed again if the email cannot be sent, so that
+// the visitor can try again with the same username.
+func (s *RegistrationService) Register(ctx context.Context, in RegistrationInput) (domain.User, error) {
+	in.Username = strings.TrimSpace(in.Username)
+	in.Email = strings.ToLower(strings.TrimSpace(in.Email))
+	if in.Password != in.PasswordConfirm {
+		return domain.User{}, domain.ErrPasswordMismatch
+	}
+	if err := domain.CheckPasswordStrength(in.Password, in.Username, in.Email); err != nil {
+		return domain.User{}, err
+	}
+
+	user, err := s.users.CreateUser(ctx, UserInput{
+		Username: in.Username,
+		Email:    in.Email,
+		Password: in.Password,
+		Status:   domain.StatusPending,
+		Role:     domain.RoleViewer,
+	})
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	if err := s.sendVerification(ctx, user); err != nil {
+		if delErr := s.users.DeleteUser(ctx, strconv.FormatInt(user.ID, 10)); delErr != nil {
+			return domain.User{}, errors.Join(err, delErr)
+		}
+		return domain.User{}, err
+	}
+	return user, nil
+}
+
+// sendVerification emails the user a link that verifies their address.
+func (s *RegistrationService) sendVerification(ctx context.Context, user domain.User) error {
+	now := time.Now()
+	token, err := s.tokens.SignFor(purposeVerifyEmail, Claims{
+		Email: user.Email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatInt(user.ID, 10),
+			ExpiresAt: jwt.NewNumericDate(now.Add(EmailVerificationTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	link := s.baseURL + "/verify-email?token=" + url.QueryEscape(token)
+	return s.mailer.Send(ctx, domain.Email{
+		To:      user.Email,
+		Subject: "Confirmez votre adresse email",
+		Body: fmt.Sprintf("Bonjour %s,\n\n"+
+			"Pour activer votre compte, ouvrez le lien suivant dans les %d heures :\n\n%s\n\n"+
+			"Si vous n'avez pas créé de compte, ignorez ce message.\n",
+			user.Username, int(EmailVerificationTTL.Hours()), link),
+	})
+}
+
+// VerifyEmail activates the pending account named by a verification token.
+// Following the link again once the account is active is harmless.
+func (s *RegistrationService) VerifyEmail(ctx context.Context, token string) (domain.User, error) {
+	claims, err := s.tokens.ParseFor(purposeVerifyEmail, token)
+	if err != nil {
+		return domain.User{}, domain.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetUser(ctx, claims.Subject)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return domain.User{}, domain.ErrInvalidToken
+	}
+	if err != nil {
+		return domain.User{}, err
+	}
+	// Le lien ne vaut que pour l'adresse à laquelle il a été envoyé
+	if user.Email != claims.Email {
+		return domain.User{}, domain.ErrInvalidToken
+	}
+
+	if user.Status == domain.StatusActive {
+		return user, nil
+	}
+	if user.Status != domain.StatusPending {
+		return domain.User{}, user.CheckActive()
+	}
+
+	if err := s.userRepo.UpdateUserStatus(ctx, claims.Subject, domain.StatusActive, ""); err != nil {
+		return domain.User{}, err
+	}
+	user.Status = domain.StatusActive
+	user.StatusReason = ""
+	return user, nil
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// SecretBox encrypts secrets stored in the database, such as TOTP secrets,
+// with AES-256-GCM, so that a copy of the database is not enough to
+// generate codes.
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+// NewSecretBox returns a box using a 32-byte key.
+func NewSecretBox(key []byte) (*SecretBox, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("secret box needs a 32-byte key, got %d bytes", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretBox{aead: aead}, nil
+}
+
+// Seal encrypts a secret; the random nonce is prepended to the result.
+func (b *SecretBox) Seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return b.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Open decrypts a secret sealed with the same key.
+func (b *SecretBox) Open(sealed []byte) ([]byte, error) {
+	size := b.aead.NonceSize()
+	if len(sealed) < size {
+		return nil, errors.New("sealed secret too short")
+	}
+	return b.aead.Open(nil, sealed[:size], sealed[size:], nil)
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"bytes"
+	"testing"
+)
+
+func newTestSecretBox(t *testing.T, key string) *SecretBox {
+	t.Helper()
+	box, err := NewSecretBox([]byte(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return box
+}
+
+func TestSecretBoxRoundTrip(t *testing.T) {
+	box := newTestSecretBox(t, "0123456789abcdef0123456789abcdef")
+	secret := []byte("12345678901234567890")
+
+	sealed, err := box.Seal(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, secret) {
+		t.Error("Seal() left the secret in clear")
+	}
+	opened, err := box.Open(sealed)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if !bytes.Equal(opened, secret) {
+		t.Errorf("Open() = %q, want %q", opened, secret)
+	}
+
+	again, err := box.Seal(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(again, sealed) {
+		t.Error("Seal() reused a nonce")
+	}
+}
+
+func TestSecretBoxRefusesTamperedOrForeignSecrets(t *testing.T) {
+	box := newTestSecretBox(t, "0123456789abcdef0123456789abcdef")
+	other := newTestSecretBox(t, "fedcba9876543210fedcba9876543210")
+
+	sealed, err := box.Seal([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 1
+
+	if _, err := box.Open(tampered); err == nil {
+		t.Error("Open() accepted a tampered secret")
+	}
+	if _, err := other.Open(sealed); err == nil {
+		t.Error("Open() accepted a secret sealed with another key")
+	}
+	if _, err := box.Open(sealed[:4]); err == nil {
+		t.Error("Open() accepted a truncated secret")
+	}
+}
+
+func TestNewSecretBoxKeySize(t *testing.T) {
+	if _, err := NewSecretBox([]byte("too short")); err == nil {
+		t.Error("NewSecretBox() accepted a short key")
+	}
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"spahtmx/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims are the claims of an access token: the username as subject, the
+// session as token ID and the user's role. Tokens for other purposes use
+// the subject and email as they need.
+type Claims struct {
+	Role  domain.Role `json:"role,omitempty"`
+	Email string      `json:"email,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// SigningKey is a key that signs and verifies tokens, identified in their
+// "kid" header.
+type SigningKey struct {
+	ID        string
+	method    jwt.SigningMethod
+	signKey   crypto.PrivateKey
+	verifyKey crypto.PublicKey
+}
+
+// NewHMACKey returns an HS256 key using the secret.
+func NewHMACKey(id, secret string) (SigningKey, error) {
+	if id == "" || secret == "" {
+		return SigningKey{}, fmt.Errorf("HS256 key %q: empty key ID or secret", id)
+	}
+	return SigningKey{
+		ID:        id,
+		method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}, nil
+}
+
+// NewEd25519Key returns an EdDSA key using the Ed25519 private key seed.
+func NewEd25519Key(id string, seed []byte) (SigningKey, error) {
+	if id == "" || len(seed) != ed25519.SeedSize {
+		return SigningKey{}, fmt.Errorf("EdDSA key %q: need a key ID and a %d-byte seed", id, ed25519.SeedSize)
+	}
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	return SigningKey{
+		ID:        id,
+		method:    jwt.SigningMethodEdDSA,
+		signKey:   privateKey,
+		verifyKey: privateKey.Public(),
+	}, nil
+}
+
+// TokenService signs access tokens and checks those it receives. Tokens are
+// signed with the first key; the others are only used to verify tokens, so
+// that a new key can be rolled out before the old one is dropped.
+type TokenService struct {
+	issuer   string
+	audience string
+	keys     []SigningKey
+}
+
+func NewTokenService(issuer, audience string, keys []SigningKey) (*TokenService, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("token service needs at least one key")
+	}
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if seen[key.ID] {
+			return nil, fmt.Errorf("duplicate key ID %q", key.ID)
+		}
+		seen[key.ID] = true
+	}
+	return &TokenService{
+		issuer:   issuer,
+		audience: audience,
+		keys:     keys,
+	}, nil
+}
+
+// Sign sets the issuer and audience of the claims and signs them with the
+// current key.
+func (s *TokenService) Sign(claims Claims) (string, error) {
+	return s.sign(s.audience, claims)
+}
+
+// SignFor signs a token for another purpose than access, such as an email
+// link. Its audience names the purpose, so that it is refused as an access
+// token and access tokens are refused in its place.
+func (s *TokenService) SignFor(purpose string, claims Claims) (string, error) {
+	return s.sign(s.audience+"/"+purpose, claims)
+}
+
+func (s *TokenService) sign(audience string, claims Claims) (string, error) {
+	key := s.keys[0]
+	claims.Issuer = s.issuer
+	claims.Audience = jwt.ClaimStrings{audience}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.signKey)
+}
+
+// Parse verifies a token and returns its claims. The token must name one of
+// the keys, be signed with that key's algorithm, come from this issuer for
+// this audience and carry an expiry that has not passed.
+func (s *TokenService) Parse(tokenString string) (*Claims, error) {
+	return s.parse(s.audience, tokenString)
+}
+
+// ParseFor verifies a token signed by SignFor for the same purpose.
+func (s *TokenService) ParseFor(purpose, tokenString string) (*Claims, error) {
+	return s.parse(s.audience+"/"+purpose, tokenString)
+}
+
+func (s *TokenService) parse(audience, tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, s.verifyKey,
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || !token.Valid {
+		return nil, ErrUnauthorized
+	}
+	return claims, nil
+}
+
+// verifyKey returns the key named by the token's "kid" header, refusing a
+// token whose algorithm is not the key's.
+func (s *TokenService) verifyKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	for _, key := range s.keys {
+		if key.ID != kid {
+			continue
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("key %q does not use %s", kid, token.Method.Alg())
+		}
+		return key.verifyKey, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "spahtmx"
+	testAudience = "spahtmx"
+	testSecret   = "test-secret"
+)
+
+var testSeed = []byte("0123456789abcdef0123456789abcdef")
+
+func newTestTokenService(t *testing.T) *TokenService {
+	t.Helper()
+	hmacKey, err := NewHMACKey("hs", testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edKey, err := NewEd25519Key("ed", testSeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewTokenService(testIssuer, testAudience, []SigningKey{hmacKey, edKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func testClaims(audience string, expires bool) Claims {
+	now := time.Now()
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:  "alice",
+			Issuer:   testIssuer,
+			Audience: jwt.ClaimStrings{audience},
+			IssuedAt: jwt.NewNumericDate(now),
+		},
+	}
+	if expires {
+		claims.ExpiresAt = jwt.NewNumericDate(now.Add(time.Minute))
+	}
+	return claims
+}
+
+// signTestToken signs the claims with the method and key, naming kid in the
+// header when it is not empty.
+func signTestToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestTokenServiceParse(t *testing.T) {
+	s := newTestTokenService(t)
+	edPublic := ed25519.NewKeyFromSeed(testSeed).Public().(ed25519.PublicKey)
+
+	signed, err := s.Sign(testClaims("", true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	emailLink, err := s.SignFor("verify-email", testClaims("", true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"signed by the service", signed, true},
+		{"EdDSA with the EdDSA kid", signTestToken(t, jwt.SigningMethodEdDSA, "ed", ed25519.NewKeyFromSeed(testSeed), testClaims(testAudience, true)), true},
+		{"HS256 with the EdDSA kid", signTestToken(t, jwt.SigningMethodHS256, "ed", []byte(edPublic), testClaims(testAudience, true)), false},
+		{"HS256 without kid", signTestToken(t, jwt.SigningMethodHS256, "", []byte(testSecret), testClaims(testAudience, true)), false},
+		{"unknown kid", signTestToken(t, jwt.SigningMethodHS256, "old", []byte(testSecret), testClaims(testAudience, true)), false},
+		{"other purpose", emailLink, false},
+		{"wrong audience", signTestToken(t, jwt.SigningMethodHS256, "hs", []byte(testSecret), testClaims("other", true)), false},
+		{"no expiry", signTestToken(t, jwt.SigningMethodHS256, "hs", []byte(testSecret), testClaims(testAudience, false)), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := s.Parse(tt.token)
+			if tt.valid {
+				if err != nil {
+					t.Fatalf("Parse() error = %v", err)
+				}
+				if claims.Subject != "alice" {
+					t.Errorf("Parse() subject = %q, want alice", claims.Subject)
+				}
+				return
+			}
+			if err != ErrUnauthorized {
+				t.Errorf("Parse() error = %v, want ErrUnauthorized", err)
+			}
+		})
+	}
+}
+
+func TestTokenServiceParseFor(t *testing.T) {
+	s := newTestTokenService(t)
+
+	link, err := s.SignFor("verify-email", testClaims("", true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ParseFor("verify-email", link); err != nil {
+		t.Errorf("ParseFor() same purpose error = %v", err)
+	}
+	if _, err := s.ParseFor("reset-password", link); err != ErrUnauthorized {
+		t.Errorf("ParseFor() other purpose error = %v, want ErrUnauthorized", err)
+	}
+
+	access, err := s.Sign(testClaims("", true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ParseFor("verify-email", access); err != ErrUnauthorized {
+		t.Errorf("ParseFor() access token error = %v, want ErrUnauthorized", err)
+	}
+}
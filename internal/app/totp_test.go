@@ -0,0 +1,70 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 secret of the RFC 6238 test vectors.
+var rfc6238Secret = []byte("12345678901234567890")
+
+func TestTOTPCode(t *testing.T) {
+	// Vecteurs de l'annexe B de la RFC 6238, réduits à six chiffres
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got := totpCode(rfc6238Secret, totpStep(time.Unix(tt.unix, 0)))
+		if got != tt.want {
+			t.Errorf("totpCode at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTOTPSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := totpStep(now)
+
+	tests := []struct {
+		name   string
+		offset int64
+		match  bool
+	}{
+		{"current step", 0, true},
+		{"previous step", -1, true},
+		{"next step", 1, true},
+		{"two steps late", -2, false},
+		{"two steps early", 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := totpCode(rfc6238Secret, current+tt.offset)
+			step, ok := matchTOTP(rfc6238Secret, code, now)
+			if ok != tt.match {
+				t.Fatalf("matchTOTP() ok = %v, want %v", ok, tt.match)
+			}
+			if ok && step != current+tt.offset {
+				t.Errorf("matchTOTP() step = %d, want %d", step, current+tt.offset)
+			}
+		})
+	}
+}
+
+func TestMatchTOTPRefusesMalformedCodes(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code := totpCode(rfc6238Secret, totpStep(now))
+
+	for _, bad := range []string{"", code[:5], code + "0", "abcdef"} {
+		if _, ok := matchTOTP(rfc6238Secret, bad, now); ok {
+			t.Errorf("matchTOTP(%q) accepted", bad)
+		}
+	}
+}
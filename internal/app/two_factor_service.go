@@ -0,0 +1,349 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"spahtmx/internal/domain"
+	"strings"
+	"time"
+)
+
+// RecoveryCodeCount is how many recovery codes a user receives. Each one
+// replaces an authenticator code once, for users who lost their device.
+const RecoveryCodeCount = 10
+
+// TwoFactorEnrollment is what a user needs to add the account to an
+// authenticator app: the otpauth:// URI shown as a QR code and the secret
+// to type in by hand.
+type TwoFactorEnrollment struct {
+	URI    string
+	Secret string
+}
+
+// TwoFactorStatus describes the two-factor authentication of a user.
+type TwoFactorStatus struct {
+	Enabled bool
+	// Required is set when the user's role requires two-factor
+	// authentication, which then cannot be disabled.
+	Required bool
+	// RecoveryCodes is how many unused recovery codes are left.
+	RecoveryCodes int
+}
+
+// TwoFactorService manages TOTP two-factor authentication (RFC 6238): the
+// enrolment of authenticator apps, the codes checked at login, recovery
+// codes and the roles that require it. Secrets are stored encrypted.
+type TwoFactorService struct {
+	repo        domain.TwoFactorRepository
+	userRepo    domain.UserRepository
+	sessionRepo domain.SessionRepository
+	box         *SecretBox
+	audit       *AuditService
+	issuer      string
+}
+
+func NewTwoFactorService(repo domain.TwoFactorRepository, userRepo domain.UserRepository, sessionRepo domain.SessionRepository, box *SecretBox, audit *AuditService, issuer string) *TwoFactorService {
+	return &TwoFactorService{
+		repo:        repo,
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		box:         box,
+		audit:       audit,
+		issuer:      issuer,
+	}
+}
+
+// GetStatus returns the two-factor authentication status of a user.
+func (s *TwoFactorService) GetStatus(ctx context.Context, user domain.User) (TwoFactorStatus, error) {
+	var status TwoFactorStatus
+	required, err := s.Required(ctx, user.Role)
+	if err != nil {
+		return TwoFactorStatus{}, err
+	}
+	status.Required = required
+
+	twoFactor, err := s.repo.GetTwoFactor(ctx, user.ID)
+	if errors.Is(err, domain.ErrTwoFactorMissing) {
+		return status, nil
+	}
+	if err != nil {
+		return TwoFactorStatus{}, err
+	}
+	if !twoFactor.Enabled() {
+		return status, nil
+	}
+
+	status.Enabled = true
+	status.RecoveryCodes, err = s.repo.CountRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return TwoFactorStatus{}, err
+	}
+	return status, nil
+}
+
+// Enroll starts the enrolment of an authenticator app. A pending enrolment
+// is returned again, so that a user who already scanned the code can still
+// confirm it after reloading the page.
+func (s *TwoFactorService) Enroll(ctx context.Context, user domain.User) (TwoFactorEnrollment, error) {
+	twoFactor, err := s.repo.GetTwoFactor(ctx, user.ID)
+	switch {
+	case err == nil && twoFactor.Enabled():
+		return TwoFactorEnrollment{}, fmt.Errorf("%w: two-factor authentication is already enabled", domain.ErrInvalidInput)
+	case err == nil:
+		secret, err := s.box.Open(twoFactor.EncryptedSecret)
+		if err != nil {
+			return TwoFactorEnrollment{}, err
+		}
+		return s.enrollment(user, secret), nil
+	case !errors.Is(err, domain.ErrTwoFactorMissing):
+		return TwoFactorEnrollment{}, err
+	}
+
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return TwoFactorEnrollment{}, err
+	}
+	sealed, err := s.box.Seal(secret)
+	if err != nil {
+		return TwoFactorEnrollment{}, err
+	}
+	err = s.repo.SaveTwoFactor(ctx, domain.TwoFactor{
+		UserID:          user.ID,
+		EncryptedSecret: sealed,
+		CreatedAt:       time.Now(),
+	})
+	if err != nil {
+		return TwoFactorEnrollment{}, err
+	}
+	return s.enrollment(user, secret), nil
+}
+
+func (s *TwoFactorService) enrollment(user domain.User, secret []byte) TwoFactorEnrollment {
+	return TwoFactorEnrollment{
+		URI:    totpURI(s.issuer, user.Username, secret),
+		Secret: groupSecret(totpEncoding.EncodeToString(secret)),
+	}
+}
+
+// groupSecret splits a base32 secret in groups of four characters, to be
+// typed in by hand.
+func groupSecret(secret string) string {
+	var groups []string
+	for len(secret) > 4 {
+		groups = append(groups, secret[:4])
+		secret = secret[4:]
+	}
+	return strings.Join(append(groups, secret), " ")
+}
+
+// Confirm completes a pending enrolment with a first code from the app and
+// returns the user's recovery codes, which are only shown this once.
+func (s *TwoFactorService) Confirm(ctx context.Context, user domain.User, code string) ([]string, error) {
+	twoFactor, err := s.repo.GetTwoFactor(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.Enabled() {
+		return nil, fmt.Errorf("%w: two-factor authentication is already enabled", domain.ErrInvalidInput)
+	}
+	secret, err := s.box.Open(twoFactor.EncryptedSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	step, ok := matchTOTP(secret, normalizeCode(code), now)
+	if !ok {
+		return nil, domain.ErrInvalidCode
+	}
+	twoFactor.ConfirmedAt = now
+	twoFactor.LastUsedStep = step
+	if err := s.repo.SaveTwoFactor(ctx, twoFactor); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.newRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.Record(ctx, user.Username, domain.AuditTwoFactorOn, user.Username, ""); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify checks a code from the authenticator app or an unused recovery
+// code. Each code is accepted only once.
+func (s *TwoFactorService) Verify(ctx context.Context, user domain.User, code string) error {
+	twoFactor, err := s.repo.GetTwoFactor(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if !twoFactor.Enabled() {
+		return domain.ErrTwoFactorMissing
+	}
+
+	code = normalizeCode(code)
+	if len(code) != totpDigits {
+		return s.useRecoveryCode(ctx, user, code)
+	}
+
+	secret, err := s.box.Open(twoFactor.EncryptedSecret)
+	if err != nil {
+		return err
+	}
+	step, ok := matchTOTP(secret, code, time.Now())
+	if !ok || step <= twoFactor.LastUsedStep {
+		return domain.ErrInvalidCode
+	}
+	// Une requête concurrente a pu utiliser le même code entre-temps
+	return s.repo.UseTOTPStep(ctx, user.ID, step)
+}
+
+func (s *TwoFactorService) useRecoveryCode(ctx context.Context, user domain.User, code string) error {
+	if code == "" {
+		return domain.ErrInvalidCode
+	}
+	if err := s.repo.UseRecoveryCode(ctx, user.ID, hashToken(code), time.Now()); err != nil {
+		return err
+	}
+	left, err := s.repo.CountRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	detail := fmt.Sprintf("%d codes restants", left)
+	return s.audit.Record(ctx, user.Username, domain.AuditRecoveryUsed, user.Username, detail)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user, once the
+// user proved to still have the app with a code.
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, user domain.User, code string) ([]string, error) {
+	if err := s.Verify(ctx, user, code); err != nil {
+		return nil, err
+	}
+	return s.newRecoveryCodes(ctx, user.ID)
+}
+
+// newRecoveryCodes replaces the recovery codes of a user and returns them.
+// Only their hashes are stored.
+func (s *TwoFactorService) newRecoveryCodes(ctx context.Context, userID int64) ([]string, error) {
+	codes := make([]string, 0, RecoveryCodeCount)
+	hashes := make([]string, 0, RecoveryCodeCount)
+	for range RecoveryCodeCount {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := hex.EncodeToString(b)
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashes = append(hashes, hashToken(code))
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable removes the authenticator app of a user, after checking a code.
+// It is refused when the user's role requires two-factor authentication.
+func (s *TwoFactorService) Disable(ctx context.Context, user domain.User, code string) error {
+	required, err := s.Required(ctx, user.Role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return domain.ErrTwoFactorNeeded
+	}
+	if err := s.Verify(ctx, user, code); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteTwoFactor(ctx, user.ID); err != nil {
+		return err
+	}
+	return s.audit.Record(ctx, user.Username, domain.AuditTwoFactorOff, user.Username, "")
+}
+
+// Required reports whether users of the role must use two-factor
+// authentication.
+func (s *TwoFactorService) Required(ctx context.Context, role domain.Role) (bool, error) {
+	policies, err := s.GetPolicies(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, policy := range policies {
+		if policy.Role == role {
+			return policy.RequireTwoFactor, nil
+		}
+	}
+	return false, nil
+}
+
+// GetPolicies returns the policy of every role, from least to most
+// privileged. Roles without a stored policy do not require anything.
+func (s *TwoFactorService) GetPolicies(ctx context.Context) ([]domain.RolePolicy, error) {
+	stored, err := s.repo.GetRolePolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	policies := make([]domain.RolePolicy, 0, len(domain.Roles))
+	for _, role := range domain.Roles {
+		policy := domain.RolePolicy{Role: role}
+		for _, p := range stored {
+			if p.Role == role {
+				policy = p
+			}
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// SetPolicy changes whether a role requires two-factor authentication.
+// When it becomes required, users of the role without an authenticator app
+// are logged out, so that they set one up at their next login.
+func (s *TwoFactorService) SetPolicy(ctx context.Context, actor string, role domain.Role, require bool) error {
+	role, err := domain.ParseRole(string(role))
+	if err != nil {
+		return err
+	}
+	if err := s.repo.SaveRolePolicy(ctx, domain.RolePolicy{Role: role, RequireTwoFactor: require}); err != nil {
+		return err
+	}
+
+	detail := "Double authentification facultative"
+	if require {
+		detail = "Double authentification obligatoire"
+		if err := s.revokeWithoutTwoFactor(ctx, role); err != nil {
+			return err
+		}
+	}
+	return s.audit.Record(ctx, actor, domain.AuditRolePolicy, string(role), detail)
+}
+
+// revokeWithoutTwoFactor logs out the users of the role who have not
+// enabled two-factor authentication.
+func (s *TwoFactorService) revokeWithoutTwoFactor(ctx context.Context, role domain.Role) error {
+	users, err := s.userRepo.GetUsers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.Role != role {
+			continue
+		}
+		twoFactor, err := s.repo.GetTwoFactor(ctx, user.ID)
+		if err != nil && !errors.Is(err, domain.ErrTwoFactorMissing) {
+			return err
+		}
+		if err == nil && twoFactor.Enabled() {
+			continue
+		}
+		if err := s.sessionRepo.DeleteUserSessions(ctx, user.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
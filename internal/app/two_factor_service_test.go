@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"spahtmx/internal/domain"
+	"testing"
+	"time"
+)
+
+// fakeTwoFactorRepository keeps one enrolment in memory. Methods the tests
+// do not use are left to the embedded nil interface.
+type fakeTwoFactorRepository struct {
+	domain.TwoFactorRepository
+	twoFactor domain.TwoFactor
+}
+
+func (r *fakeTwoFactorRepository) GetTwoFactor(ctx context.Context, userID int64) (domain.TwoFactor, error) {
+	return r.twoFactor, nil
+}
+
+// UseTOTPStep records the step only when it is newer, like the database.
+func (r *fakeTwoFactorRepository) UseTOTPStep(ctx context.Context, userID int64, step int64) error {
+	if step <= r.twoFactor.LastUsedStep {
+		return domain.ErrInvalidCode
+	}
+	r.twoFactor.LastUsedStep = step
+	return nil
+}
+
+func TestTwoFactorVerifyRefusesReplayedCodes(t *testing.T) {
+	ctx := context.Background()
+	box := newTestSecretBox(t, "0123456789abcdef0123456789abcdef")
+	sealed, err := box.Seal(rfc6238Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &fakeTwoFactorRepository{twoFactor: domain.TwoFactor{
+		UserID:          1,
+		EncryptedSecret: sealed,
+		ConfirmedAt:     time.Now(),
+	}}
+	s := NewTwoFactorService(repo, nil, nil, box, nil, "test")
+	user := domain.User{ID: 1, Username: "alice"}
+
+	current := totpStep(time.Now())
+	code := totpCode(rfc6238Secret, current)
+	if err := s.Verify(ctx, user, code); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if err := s.Verify(ctx, user, code); !errors.Is(err, domain.ErrInvalidCode) {
+		t.Errorf("Verify() replayed code error = %v, want ErrInvalidCode", err)
+	}
+	// Le code précédent reste dans la tolérance mais est plus ancien
+	previous := totpCode(rfc6238Secret, current-1)
+	if err := s.Verify(ctx, user, previous); !errors.Is(err, domain.ErrInvalidCode) {
+		t.Errorf("Verify() older code error = %v, want ErrInvalidCode", err)
+	}
+	next := totpCode(rfc6238Secret, current+1)
+	if err := s.Verify(ctx, user, next); err != nil {
+		t.Errorf("Verify() next code error = %v", err)
+	}
+}
+
+func TestTwoFactorVerifyNeedsConfirmedEnrolment(t *testing.T) {
+	box := newTestSecretBox(t, "0123456789abcdef0123456789abcdef")
+	sealed, err := box.Seal(rfc6238Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &fakeTwoFactorRepository{twoFactor: domain.TwoFactor{UserID: 1, EncryptedSecret: sealed}}
+	s := NewTwoFactorService(repo, nil, nil, box, nil, "test")
+
+	code := totpCode(rfc6238Secret, totpStep(time.Now()))
+	if err := s.Verify(context.Background(), domain.User{ID: 1}, code); !errors.Is(err, domain.ErrTwoFactorMissing) {
+		t.Errorf("Verify() error = %v, want ErrTwoFactorMissing", err)
+	}
+}
@@ -2,34 +2,238 @@ package app
 
 import (
 	"context"
+	"errors"
 	"spahtmx/internal/domain"
+	"strings"
+	"time"
 )
 
 type UserService struct {
 	repo domain.UserRepository
+	auth *AuthService
 }
 
-func NewUserService(r domain.UserRepository) *UserService {
+func NewUserService(r domain.UserRepository, auth *AuthService) *UserService {
 	return &UserService{
 		repo: r,
+		auth: auth,
 	}
 }
 
+// UserInput holds the fields of the user forms. On update, an empty password
+// keeps the current one. An empty status defaults to active and an empty
+// role to viewer.
+type UserInput struct {
+	Username     string
+	Email        string
+	Password     string
+	Status       domain.UserStatus
+	StatusReason string
+	Role         domain.Role
+}
+
+func (in UserInput) normalize() UserInput {
+	in.Username = strings.TrimSpace(in.Username)
+	in.Email = strings.ToLower(strings.TrimSpace(in.Email))
+	if in.Role == "" {
+		in.Role = domain.RoleViewer
+	}
+	if in.Status == "" {
+		in.Status = domain.StatusActive
+	}
+	in.StatusReason = strings.TrimSpace(in.StatusReason)
+	switch {
+	case in.Status == domain.StatusActive:
+		in.StatusReason = ""
+	case in.StatusReason == "" && in.Status == domain.StatusPending:
+		in.StatusReason = domain.PendingVerificationReason
+	case in.StatusReason == "":
+		in.StatusReason = domain.DisabledByAdminReason
+	}
+	return in
+}
+
 func (s *UserService) GetUsers(ctx context.Context) ([]domain.User, error) {
 	return s.repo.GetUsers(ctx)
 }
 
-func (s *UserService) UpdateUserStatus(ctx context.Context, id string) error {
+func (s *UserService) GetUser(ctx context.Context, id string) (domain.User, error) {
 	if id == "" {
-		return domain.ErrInvalidInput
+		return domain.User{}, domain.ErrInvalidInput
+	}
+	return s.repo.GetUser(ctx, id)
+}
+
+// CreateUser validates the input, hashes the password and stores the user.
+func (s *UserService) CreateUser(ctx context.Context, in UserInput) (domain.User, error) {
+	in = in.normalize()
+	if err := domain.ValidatePassword(in.Password); err != nil {
+		return domain.User{}, err
+	}
+	if err := s.validate(ctx, in, 0); err != nil {
+		return domain.User{}, err
+	}
+
+	hash, err := s.auth.HashPassword(in.Password)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	user := domain.User{
+		Username:     in.Username,
+		Password:     hash,
+		Email:        in.Email,
+		Status:       in.Status,
+		StatusReason: in.StatusReason,
+		Role:         in.Role,
+	}
+	if err := s.repo.CreateUser(ctx, user); err != nil {
+		return domain.User{}, err
+	}
+	return s.repo.GetByUsername(ctx, user.Username)
+}
+
+// UpdateUser validates the input and applies it to the user. The last active
+// administrator can be neither disabled nor demoted.
+func (s *UserService) UpdateUser(ctx context.Context, id string, in UserInput) (domain.User, error) {
+	user, err := s.GetUser(ctx, id)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	in = in.normalize()
+	if err := s.validate(ctx, in, user.ID); err != nil {
+		return domain.User{}, err
+	}
+	if in.Password != "" {
+		if err := domain.ValidatePassword(in.Password); err != nil {
+			return domain.User{}, err
+		}
+		if user.Password, err = s.auth.HashPassword(in.Password); err != nil {
+			return domain.User{}, err
+		}
+	}
+
+	if !in.Role.Includes(domain.RoleAdmin) || in.Status != domain.StatusActive {
+		if err := s.checkNotLastAdmin(ctx, user); err != nil {
+			return domain.User{}, err
+		}
+	}
+
+	if in.Status != user.Status {
+		// Un verrouillage posé par un administrateur n'expire pas
+		user.LockedUntil = time.Time{}
+	}
+	user.Username = in.Username
+	user.Email = in.Email
+	user.Status = in.Status
+	user.StatusReason = in.StatusReason
+	user.Role = in.Role
+	if err := s.repo.UpdateUser(ctx, user); err != nil {
+		return domain.User{}, err
+	}
+	if !user.IsActive() {
+		if err := s.auth.RevokeAllSessions(ctx, user.ID); err != nil {
+			return domain.User{}, err
+		}
 	}
-	return s.repo.UpdateUserStatus(ctx, id)
+	return user, nil
 }
 
-func (s *UserService) GetUserCount(ctx context.Context) string {
-	return "1234"
+// DeleteUser deletes the user after logging them out everywhere. The last
+// active administrator cannot be deleted.
+func (s *UserService) DeleteUser(ctx context.Context, id string) error {
+	user, err := s.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.checkNotLastAdmin(ctx, user); err != nil {
+		return err
+	}
+	if err := s.auth.RevokeAllSessions(ctx, user.ID); err != nil {
+		return err
+	}
+	return s.repo.DeleteUser(ctx, id)
+}
+
+// checkNotLastAdmin returns domain.ErrLastAdmin when the user is the only
+// active administrator.
+func (s *UserService) checkNotLastAdmin(ctx context.Context, user domain.User) error {
+	if !user.Role.Includes(domain.RoleAdmin) || !user.IsActive() {
+		return nil
+	}
+	last, err := lastAdmin(ctx, s.repo, user)
+	if err != nil {
+		return err
+	}
+	if last {
+		return domain.ErrLastAdmin
+	}
+	return nil
+}
+
+// lastAdmin reports whether the administrator is the only active one.
+func lastAdmin(ctx context.Context, repo domain.UserRepository, admin domain.User) (bool, error) {
+	users, err := repo.GetUsers(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, u := range users {
+		if u.ID != admin.ID && u.Role.Includes(domain.RoleAdmin) && u.IsActive() {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// validate checks the formats and that no user other than the one with the
+// given id already has the username or email.
+func (s *UserService) validate(ctx context.Context, in UserInput, id int64) error {
+	if err := domain.ValidateUsername(in.Username); err != nil {
+		return err
+	}
+	if err := domain.ValidateEmail(in.Email); err != nil {
+		return err
+	}
+	if _, err := domain.ParseRole(string(in.Role)); err != nil {
+		return err
+	}
+	if _, err := domain.ParseUserStatus(string(in.Status)); err != nil {
+		return err
+	}
+
+	if other, err := s.repo.GetByUsername(ctx, in.Username); err == nil && other.ID != id {
+		return domain.ErrUsernameTaken
+	} else if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		return err
+	}
+	if other, err := s.repo.GetByEmail(ctx, in.Email); err == nil && other.ID != id {
+		return domain.ErrEmailTaken
+	} else if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		return err
+	}
+	return nil
+}
+
+// UpdateUserStatus disables an active user, ending their sessions, and
+// reactivates any other. The last active administrator cannot be disabled.
+func (s *UserService) UpdateUserStatus(ctx context.Context, id string) error {
+	user, err := s.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive() {
+		return s.repo.UpdateUserStatus(ctx, id, domain.StatusActive, "")
+	}
+	if err := s.checkNotLastAdmin(ctx, user); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateUserStatus(ctx, id, domain.StatusDisabled, domain.DisabledByAdminReason); err != nil {
+		return err
+	}
+	return s.auth.RevokeAllSessions(ctx, user.ID)
 }
 
-func (s *UserService) GetPageView(ctx context.Context) string {
-	return "1212121"
+func (s *UserService) GetUserCount(ctx context.Context) (int, error) {
+	return s.repo.CountUsers(ctx)
 }
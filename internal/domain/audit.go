@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// AuditAction is a security-relevant event recorded in the audit log.
+type AuditAction string
+
+const (
+	AuditLoginBlocked    AuditAction = "login_blocked"
+	AuditLoginUnblocked  AuditAction = "login_unblocked"
+	AuditAccountLocked   AuditAction = "account_locked"
+	AuditAccountUnlocked AuditAction = "account_unlocked"
+	AuditTwoFactorOn     AuditAction = "two_factor_enabled"
+	AuditTwoFactorOff    AuditAction = "two_factor_disabled"
+	AuditRecoveryUsed    AuditAction = "recovery_code_used"
+	AuditRolePolicy      AuditAction = "role_policy_changed"
+)
+
+// AuditEntry records who did what to which account or address. Actor is
+// empty for actions taken by the application itself.
+type AuditEntry struct {
+	ID     int64
+	At     time.Time
+	Actor  string
+	Action AuditAction
+	Target string
+	Detail string
+}
@@ -0,0 +1,44 @@
+package domain
+
+import "fmt"
+
+// Category is one of the Nobel prize categories.
+type Category string
+
+const (
+	CategoryChemistry  Category = "chemistry"
+	CategoryEconomics  Category = "economics"
+	CategoryLiterature Category = "literature"
+	CategoryMedicine   Category = "medicine"
+	CategoryPeace      Category = "peace"
+	CategoryPhysics    Category = "physics"
+)
+
+// Categories lists every known category in alphabetical order.
+var Categories = []Category{
+	CategoryChemistry,
+	CategoryEconomics,
+	CategoryLiterature,
+	CategoryMedicine,
+	CategoryPeace,
+	CategoryPhysics,
+}
+
+// ParseCategory validates a category name.
+func ParseCategory(s string) (Category, error) {
+	c := Category(s)
+	if !c.Valid() {
+		return "", fmt.Errorf("%w: unknown category %q", ErrInvalidInput, s)
+	}
+	return c, nil
+}
+
+// Valid reports whether c is one of the known categories.
+func (c Category) Valid() bool {
+	for _, known := range Categories {
+		if c == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package domain
+
+// PrizeSharing restricts a listing to prizes awarded to a single laureate or
+// shared between several.
+type PrizeSharing string
+
+const (
+	SharingAny    PrizeSharing = ""
+	SharingSolo   PrizeSharing = "solo"
+	SharingShared PrizeSharing = "shared"
+)
+
+// PrizeCriteria describes which prizes a listing shows and in which order.
+// Zero fields leave the corresponding filter out, so the zero value matches
+// every prize. Year bounds are inclusive.
+type PrizeCriteria struct {
+	// Query is free text matched against motivations and laureate names.
+	Query                string
+	Categories           []Category
+	YearFrom             int
+	YearTo               int
+	HasOverallMotivation bool
+	MinLaureates         int
+	Sharing              PrizeSharing
+	// LaureateKind keeps prizes whose laureates are all of that kind.
+	LaureateKind LaureateKind
+	Sort         PrizeSort
+}
+
+// IsZero reports whether no filter is set. The sort order is not a filter.
+func (c PrizeCriteria) IsZero() bool {
+	return c.Query == "" && len(c.Categories) == 0 && c.YearFrom == 0 && c.YearTo == 0 &&
+		!c.HasOverallMotivation && c.MinLaureates == 0 && c.Sharing == SharingAny &&
+		c.LaureateKind == ""
+}
+
+// Terms returns the words of the free-text query.
+func (c PrizeCriteria) Terms() []string {
+	return SearchTerms(c.Query)
+}
+
+// HasCategory reports whether the category is one of the selected ones.
+func (c PrizeCriteria) HasCategory(category Category) bool {
+	for _, cat := range c.Categories {
+		if cat == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate rejects inconsistent criteria, such as a year range that ends
+// before it starts.
+func (c PrizeCriteria) Validate() error {
+	if c.YearFrom < 0 || c.YearTo < 0 {
+		return ErrInvalidInput
+	}
+	if c.YearFrom != 0 && c.YearTo != 0 && c.YearFrom > c.YearTo {
+		return ErrInvalidInput
+	}
+	if c.MinLaureates < 0 {
+		return ErrInvalidInput
+	}
+	if c.Sort != "" {
+		if _, err := ParsePrizeSort(string(c.Sort)); err != nil {
+			return err
+		}
+	}
+	switch c.Sharing {
+	case SharingAny, SharingSolo, SharingShared:
+	default:
+		return ErrInvalidInput
+	}
+	if c.LaureateKind != "" && !c.LaureateKind.Valid() {
+		return ErrInvalidInput
+	}
+	for _, cat := range c.Categories {
+		if !cat.Valid() {
+			return ErrInvalidInput
+		}
+	}
+	return nil
+}
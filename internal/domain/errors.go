@@ -1,10 +1,30 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
-	ErrUserNotFound = errors.New("user not found")
-	PrizeNotFound   = errors.New("prize not found")
-	ErrInternal     = errors.New("internal error")
-	ErrInvalidInput = errors.New("invalid input")
+	ErrUserNotFound     = errors.New("user not found")
+	PrizeNotFound       = errors.New("prize not found")
+	ErrLaureateNotFound = errors.New("laureate not found")
+	ErrInternal         = errors.New("internal error")
+	ErrInvalidInput     = errors.New("invalid input")
+	ErrUsernameTaken    = errors.New("username already taken")
+	ErrEmailTaken       = errors.New("email already in use")
+	ErrAccountDisabled  = errors.New("account disabled")
+	ErrAccountLocked    = errors.New("account locked")
+	ErrAccountPending   = errors.New("account pending email verification")
+	ErrSessionNotFound  = errors.New("session not found")
+	ErrRefreshNotFound  = errors.New("refresh token not found")
+	ErrRefreshUsed      = errors.New("refresh token already used")
+	ErrTooManyAttempts  = errors.New("too many login attempts")
+	ErrInvalidToken     = errors.New("invalid or expired token")
+	ErrTwoFactorMissing = errors.New("two-factor authentication not set up")
+	ErrInvalidCode      = errors.New("invalid authentication code")
+	ErrTwoFactorNeeded  = errors.New("two-factor authentication required by role")
+	// ErrLastAdmin refuses to delete, disable or demote the only active
+	// administrator, who would leave nobody able to manage accounts.
+	ErrLastAdmin = fmt.Errorf("%w: last active administrator", ErrInvalidInput)
 )
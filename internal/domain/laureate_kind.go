@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LaureateKind tells people apart from organisations, such as the Red Cross
+// or UNHCR, which are awarded the peace prize.
+type LaureateKind string
+
+const (
+	KindPerson       LaureateKind = "person"
+	KindOrganisation LaureateKind = "organisation"
+)
+
+// ParseLaureateKind validates a laureate kind.
+func ParseLaureateKind(s string) (LaureateKind, error) {
+	k := LaureateKind(s)
+	if !k.Valid() {
+		return "", fmt.Errorf("%w: unknown laureate kind %q", ErrInvalidInput, s)
+	}
+	return k, nil
+}
+
+// Valid reports whether k is a known kind.
+func (k LaureateKind) Valid() bool {
+	return k == KindPerson || k == KindOrganisation
+}
+
+// InferLaureateKind guesses the kind of a laureate from its name: the Nobel
+// dataset stores an organisation's name as a firstname without surname.
+func InferLaureateKind(l Laureate) LaureateKind {
+	if l.Surname == "" {
+		return KindOrganisation
+	}
+	return KindPerson
+}
+
+// IsOrganisation reports whether the laureate is an organisation.
+func (l Laureate) IsOrganisation() bool {
+	return l.Kind == KindOrganisation
+}
+
+// Name is the laureate's display name: the full name of a person or the name
+// of an organisation.
+func (l Laureate) Name() string {
+	return displayName(l.Firstname, l.Surname)
+}
+
+// IsOrganisation reports whether the laureate is an organisation.
+func (p LaureateProfile) IsOrganisation() bool {
+	return p.Kind == KindOrganisation
+}
+
+// Name is the laureate's display name.
+func (p LaureateProfile) Name() string {
+	return displayName(p.Firstname, p.Surname)
+}
+
+func displayName(firstname, surname string) string {
+	return strings.TrimSpace(firstname + " " + surname)
+}
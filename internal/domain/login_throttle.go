@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LockedAfterFailuresReason is recorded when an account is locked after too
+// many failed logins.
+const LockedAfterFailuresReason = "Verrouillé après trop d'échecs de connexion"
+
+const (
+	usernameThrottlePrefix = "user:"
+	addressThrottlePrefix  = "ip:"
+)
+
+// LoginThrottle counts the recent failed logins for a username or a client
+// address, and how long further logins from it are refused.
+type LoginThrottle struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	BlockedUntil  time.Time
+}
+
+// UsernameThrottleKey is the key of the failed logins for a username,
+// whatever its case.
+func UsernameThrottleKey(username string) string {
+	return usernameThrottlePrefix + strings.ToLower(username)
+}
+
+// AddressThrottleKey is the key of the failed logins from a client address.
+func AddressThrottleKey(address string) string {
+	return addressThrottlePrefix + address
+}
+
+// Username returns the username the throttle counts failures for, if it is
+// not an address throttle.
+func (t LoginThrottle) Username() (string, bool) {
+	return strings.CutPrefix(t.Key, usernameThrottlePrefix)
+}
+
+// Address returns the client address the throttle counts failures for, if
+// it is not a username throttle.
+func (t LoginThrottle) Address() (string, bool) {
+	return strings.CutPrefix(t.Key, addressThrottlePrefix)
+}
+
+// Blocked reports whether logins are refused at the given time.
+func (t LoginThrottle) Blocked(now time.Time) bool {
+	return now.Before(t.BlockedUntil)
+}
+
+// LoginBlockedError refuses a login until too many recent failures have
+// been waited out.
+type LoginBlockedError struct {
+	Until time.Time
+}
+
+func (e *LoginBlockedError) Error() string {
+	return fmt.Sprintf("%v until %s", ErrTooManyAttempts, e.Until.Format(time.RFC3339))
+}
+
+func (e *LoginBlockedError) Unwrap() error {
+	return ErrTooManyAttempts
+}
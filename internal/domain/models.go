@@ -1,11 +1,22 @@
 package domain
 
+import (
+	"fmt"
+	"time"
+)
+
 type User struct {
 	ID       int64
 	Username string
 	Password string
 	Email    string
-	Status   bool
+	Status   UserStatus
+	// StatusReason explains why the account is not active.
+	StatusReason string
+	// LockedUntil is when a lock set after failed logins expires. It is
+	// zero for locks set by an administrator, which do not expire.
+	LockedUntil time.Time
+	Role        Role
 }
 
 type PrizeList struct {
@@ -13,16 +24,79 @@ type PrizeList struct {
 }
 
 type Prize struct {
-	ID                int64     `json:"id"`
-	Year              string     `json:"year"`
-	Category          string     `json:"category"`
+	ID                int64      `json:"id"`
+	Year              int        `json:"year,string"`
+	Category          Category   `json:"category"`
 	OverallMotivation string     `json:"overallMotivation,omitempty"`
 	Laureates         []Laureate `json:"laureates,omitempty"`
 }
 
+// FirstPrizeYear is the year the Nobel prizes were first awarded.
+const FirstPrizeYear = 1901
+
+// Validate checks the year and category of the prize and that each laureate
+// is identified and, when given, of a known kind.
+func (p Prize) Validate() error {
+	if p.Year < FirstPrizeYear {
+		return fmt.Errorf("%w: year %d is before %d", ErrInvalidInput, p.Year, FirstPrizeYear)
+	}
+	if _, err := ParseCategory(string(p.Category)); err != nil {
+		return err
+	}
+	for i, l := range p.Laureates {
+		if l.ID == "" {
+			return fmt.Errorf("%w: laureate %d has no id", ErrInvalidInput, i+1)
+		}
+		if l.Kind != "" && !l.Kind.Valid() {
+			return fmt.Errorf("%w: laureate %s has unknown kind %q", ErrInvalidInput, l.ID, l.Kind)
+		}
+	}
+	return nil
+}
+
 type Laureate struct {
-	Firstname  string `json:"firstname,omitempty"`
-	Surname    string `json:"surname,omitempty"`
-	Motivation string `json:"motivation,omitempty"`
-	Share      string `json:"share,omitempty"`
+	ID         string       `json:"id"`
+	Kind       LaureateKind `json:"kind,omitempty"`
+	Firstname  string       `json:"firstname,omitempty"`
+	Surname    string       `json:"surname,omitempty"`
+	Motivation string       `json:"motivation,omitempty"`
+	Share      Share        `json:"share,omitempty"`
+}
+
+// PrizeDetail is a prize together with the prizes awarded in the same
+// category in the surrounding years, ordered by year.
+type PrizeDetail struct {
+	Prize      Prize
+	Neighbours []Prize
+}
+
+// LaureateProfile is a laureate together with every prize they received.
+// Each prize lists all of its laureates, so co-laureates are available too.
+type LaureateProfile struct {
+	ID        string
+	Kind      LaureateKind
+	Firstname string
+	Surname   string
+	Prizes    []Prize
+}
+
+// Award returns the laureate's own entry (share, motivation) in the given prize.
+func (p LaureateProfile) Award(prize Prize) (Laureate, bool) {
+	for _, l := range prize.Laureates {
+		if l.ID == p.ID {
+			return l, true
+		}
+	}
+	return Laureate{}, false
+}
+
+// CoLaureates returns the other laureates who shared the given prize.
+func (p LaureateProfile) CoLaureates(prize Prize) []Laureate {
+	var others []Laureate
+	for _, l := range prize.Laureates {
+		if l.ID != p.ID {
+			others = append(others, l)
+		}
+	}
+	return others
 }
@@ -0,0 +1,49 @@
+package domain
+
+import "strings"
+
+// DefaultNotAwardedReason is shown when the records give no reason for a
+// prize not being awarded.
+const DefaultNotAwardedReason = "Aucun prix Nobel n'a été décerné cette année."
+
+// NotAwarded records a category and year for which no prize was awarded,
+// for instance during the world wars, and why.
+type NotAwarded struct {
+	Category Category
+	Year     int
+	Reason   string
+}
+
+// NotAwarded reports whether the prize record stands for a year in which the
+// prize was not awarded, which the Nobel dataset lists as a prize without
+// laureates. The overall motivation then holds the reason.
+func (p Prize) NotAwarded() (NotAwarded, bool) {
+	if len(p.Laureates) > 0 {
+		return NotAwarded{}, false
+	}
+	return NotAwarded{
+		Category: p.Category,
+		Year:     p.Year,
+		Reason:   strings.Trim(strings.TrimSpace(p.OverallMotivation), `"`),
+	}, true
+}
+
+// Explanation returns the reason, or a generic note when none is recorded.
+func (n NotAwarded) Explanation() string {
+	if n.Reason == "" {
+		return DefaultNotAwardedReason
+	}
+	return n.Reason
+}
+
+// OnlyCategoriesAndYears reports whether the criteria select prizes by
+// category or year and by nothing else, the only filters for which a missing
+// prize can be explained by it not having been awarded.
+func (c PrizeCriteria) OnlyCategoriesAndYears() bool {
+	if len(c.Categories) == 0 && c.YearFrom == 0 && c.YearTo == 0 {
+		return false
+	}
+	rest := c
+	rest.Categories, rest.YearFrom, rest.YearTo = nil, 0, 0
+	return rest.IsZero()
+}
@@ -0,0 +1,121 @@
+package domain
+
+import "time"
+
+// PageView is one page served, as recorded for analytics. It carries no
+// information about the visitor beyond the host of the referring page.
+type PageView struct {
+	Route        string
+	HTMX         bool
+	Status       int
+	Latency      time.Duration
+	ReferrerHost string
+	At           time.Time
+}
+
+// LatencyBucket groups response times into coarse ranges.
+type LatencyBucket string
+
+const (
+	LatencyFast   LatencyBucket = "<50ms"
+	LatencyMedium LatencyBucket = "50-200ms"
+	LatencySlow   LatencyBucket = "200ms-1s"
+	LatencyStuck  LatencyBucket = ">1s"
+)
+
+// LatencyBuckets lists the buckets from fastest to slowest.
+var LatencyBuckets = []LatencyBucket{LatencyFast, LatencyMedium, LatencySlow, LatencyStuck}
+
+// BucketLatency returns the bucket a response time falls in.
+func BucketLatency(d time.Duration) LatencyBucket {
+	switch {
+	case d < 50*time.Millisecond:
+		return LatencyFast
+	case d < 200*time.Millisecond:
+		return LatencyMedium
+	case d < time.Second:
+		return LatencySlow
+	default:
+		return LatencyStuck
+	}
+}
+
+// PageViewCount is the number of page views sharing a day, route, load type,
+// status, latency bucket and referrer host.
+type PageViewCount struct {
+	Day          time.Time
+	Route        string
+	HTMX         bool
+	Status       int
+	Latency      LatencyBucket
+	ReferrerHost string
+	Count        int
+}
+
+// PageViewTotals are the page views of today, of the last seven days
+// (today included) and since the counter started.
+type PageViewTotals struct {
+	Today    int
+	LastWeek int
+	AllTime  int
+}
+
+// Add adds the views of a day to the totals relative to today.
+func (t *PageViewTotals) Add(day, today time.Time, count int) {
+	t.AllTime += count
+	if !day.Before(today.AddDate(0, 0, -6)) {
+		t.LastWeek += count
+	}
+	if day.Equal(today) {
+		t.Today += count
+	}
+}
+
+// Day truncates a time to midnight UTC, the granularity of page view counts.
+func Day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// RouteViews sums the views of a route. HTMX counts the views loaded by htmx
+// navigation rather than a full page load; Errors those that failed.
+type RouteViews struct {
+	Route  string
+	Views  int
+	HTMX   int
+	Errors int
+}
+
+// DayViews sums the views of a day by load type.
+type DayViews struct {
+	Day  time.Time
+	Full int
+	HTMX int
+}
+
+// OtherReferrerHost stands for the referrer hosts that were not counted
+// separately, as there were too many of them.
+const OtherReferrerHost = "autre"
+
+// ReferrerViews sums the views coming from a referrer host. An empty host
+// stands for direct visits and navigation within the site.
+type ReferrerViews struct {
+	Host  string
+	Views int
+}
+
+// LatencyViews sums the views answered within a latency bucket.
+type LatencyViews struct {
+	Bucket LatencyBucket
+	Views  int
+}
+
+// PageViewReport is the analytics of the last Days days, today included.
+type PageViewReport struct {
+	Days      int
+	Totals    PageViewTotals
+	TopRoutes []RouteViews
+	Trend     []DayViews
+	Referrers []ReferrerViews
+	Latency   []LatencyViews
+}
@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PrizeSort is the order of a prize listing.
+type PrizeSort string
+
+const (
+	SortYearDesc  PrizeSort = "year_desc"
+	SortYearAsc   PrizeSort = "year_asc"
+	SortCategory  PrizeSort = "category"
+	SortLaureates PrizeSort = "laureates"
+	SortSurname   PrizeSort = "surname"
+)
+
+// PrizeSorts lists the supported orders, the default first.
+var PrizeSorts = []PrizeSort{SortYearDesc, SortYearAsc, SortCategory, SortLaureates, SortSurname}
+
+// ParsePrizeSort validates a sort parameter. An empty string selects the
+// default order, most recent year first.
+func ParsePrizeSort(s string) (PrizeSort, error) {
+	if s == "" {
+		return SortYearDesc, nil
+	}
+	for _, sort := range PrizeSorts {
+		if string(sort) == s {
+			return sort, nil
+		}
+	}
+	return "", ErrInvalidInput
+}
+
+// SortKey returns the value a prize is ordered by for sorts that are not on
+// year or category: its number of laureates, or the surname of its first
+// laureate (the name, for an organisation).
+func (p Prize) SortKey(sort PrizeSort) string {
+	switch sort {
+	case SortLaureates:
+		return strconv.Itoa(len(p.Laureates))
+	case SortSurname:
+		if len(p.Laureates) == 0 {
+			return ""
+		}
+		if p.Laureates[0].Surname != "" {
+			return p.Laureates[0].Surname
+		}
+		return p.Laureates[0].Firstname
+	}
+	return ""
+}
+
+// PrizeCursor marks the last prize of a page. Year and category break ties
+// in every order, Key holds the prize's SortKey. The zero value starts at the
+// first page.
+type PrizeCursor struct {
+	Year     int
+	Category Category
+	Key      string
+}
+
+func (c PrizeCursor) IsZero() bool {
+	return c.Year == 0 && c.Category == ""
+}
+
+// String encodes the cursor for use in a URL, e.g. "1921-physics" or
+// "1935-chemistry-Joliot-Curie".
+func (c PrizeCursor) String() string {
+	if c.IsZero() {
+		return ""
+	}
+	s := strconv.Itoa(c.Year) + "-" + string(c.Category)
+	if c.Key != "" {
+		s += "-" + c.Key
+	}
+	return s
+}
+
+// ParsePrizeCursor decodes a cursor produced by PrizeCursor.String. An empty
+// string yields the zero cursor.
+func ParsePrizeCursor(s string) (PrizeCursor, error) {
+	if s == "" {
+		return PrizeCursor{}, nil
+	}
+	y, rest, ok := strings.Cut(s, "-")
+	if !ok {
+		return PrizeCursor{}, ErrInvalidInput
+	}
+	year, err := strconv.Atoi(y)
+	if err != nil {
+		return PrizeCursor{}, ErrInvalidInput
+	}
+	c, key, _ := strings.Cut(rest, "-")
+	category, err := ParseCategory(c)
+	if err != nil {
+		return PrizeCursor{}, err
+	}
+	return PrizeCursor{Year: year, Category: category, Key: key}, nil
+}
+
+// PrizePage is one page of a prize listing. Next is nil on the last page.
+// NotAwarded explains an empty listing when the selected categories and
+// years were not awarded.
+type PrizePage struct {
+	Prizes     []Prize
+	Next       *PrizeCursor
+	NotAwarded []NotAwarded
+}
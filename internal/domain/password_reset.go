@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// PasswordReset is a request to choose a new password, proven by a token
+// emailed to the user. Only a hash of the token is stored, and it can be
+// used once before it expires.
+type PasswordReset struct {
+	Hash      string
+	UserID    int64
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	// UsedAt is when the password was reset, zero while the token is unused.
+	UsedAt time.Time
+}
+
+// Valid reports whether the token can still be used at the given time.
+func (r PasswordReset) Valid(now time.Time) bool {
+	return r.UsedAt.IsZero() && now.Before(r.ExpiresAt)
+}
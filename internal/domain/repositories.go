@@ -1,23 +1,93 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type UserRepository interface {
 	GetUsers(ctx context.Context) ([]User, error)
 	GetUser(ctx context.Context, id string) (User, error)
 	GetByUsername(ctx context.Context, username string) (User, error)
+	GetByEmail(ctx context.Context, email string) (User, error)
 	CreateUser(ctx context.Context, user User) error
 	UpdateUser(ctx context.Context, user User) error
-	UpdateUserStatus(ctx context.Context, id string) error
+	UpdateUserStatus(ctx context.Context, id string, status UserStatus, reason string) error
+	LockUser(ctx context.Context, id string, reason string, until time.Time) error
 	DeleteUser(ctx context.Context, id string) error
+	CountUsers(ctx context.Context) (int, error)
 }
 
 type PrizeRepository interface {
-	GetPrizes(ctx context.Context) ([]Prize, error)
+	Save(ctx context.Context, prize Prize) error
+	SaveNotAwarded(ctx context.Context, notAwarded NotAwarded) error
+	GetNotAwarded(ctx context.Context, criteria PrizeCriteria) ([]NotAwarded, error)
 	GetPrize(ctx context.Context, id string) (Prize, error)
-	GetPrizesByYear(ctx context.Context, year string) ([]Prize, error)
-	GetPrizesByCategory(ctx context.Context, category string) ([]Prize, error)
-	GetPrizesByCategoryAndYear(ctx context.Context, category string, year string) ([]Prize, error)
-	GetCategories(ctx context.Context) ([]string, error)
-	GetYears(ctx context.Context) ([]string, error)
+	GetNeighbourPrizes(ctx context.Context, category Category, year int, n int) ([]Prize, error)
+	GetPrizePage(ctx context.Context, criteria PrizeCriteria, after PrizeCursor, limit int) (PrizePage, error)
+	GetCategories(ctx context.Context) ([]Category, error)
+	GetYears(ctx context.Context) ([]int, error)
+	GetLaureate(ctx context.Context, id string) (LaureateProfile, error)
+	GetPrizesPerCategoryDecade(ctx context.Context, filter StatsFilter) ([]CategoryDecadeCount, error)
+	GetSharingPerDecade(ctx context.Context, filter StatsFilter) ([]SharingCount, error)
+	GetMultipleLaureates(ctx context.Context, filter StatsFilter) ([]LaureateCount, error)
+	GetAverageLaureates(ctx context.Context, filter StatsFilter) ([]CategoryAverage, error)
+}
+
+type PageViewRepository interface {
+	AddPageViews(ctx context.Context, counts []PageViewCount) error
+	GetPageViewTotals(ctx context.Context, today time.Time) (PageViewTotals, error)
+	GetTopRoutes(ctx context.Context, since time.Time, limit int) ([]RouteViews, error)
+	GetDailyViews(ctx context.Context, since time.Time) ([]DayViews, error)
+	GetTopReferrers(ctx context.Context, since time.Time, limit int) ([]ReferrerViews, error)
+	GetLatencyViews(ctx context.Context, since time.Time) ([]LatencyViews, error)
+}
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session Session) error
+	GetSession(ctx context.Context, id string) (Session, error)
+	TouchSession(ctx context.Context, id string, at time.Time) error
+	RenewSession(ctx context.Context, id string, at, expiresAt time.Time) error
+	GetUserSessions(ctx context.Context, userID int64, now time.Time) ([]Session, error)
+	GetActiveSessions(ctx context.Context, now time.Time) ([]Session, error)
+	DeleteSession(ctx context.Context, id string) error
+	DeleteUserSessions(ctx context.Context, userID int64) error
+	DeleteExpiredSessions(ctx context.Context, now time.Time) error
+	CreateRefreshToken(ctx context.Context, token RefreshToken) error
+	GetRefreshToken(ctx context.Context, hash string) (RefreshToken, error)
+	UseRefreshToken(ctx context.Context, hash string, at time.Time) error
+}
+
+type LoginThrottleRepository interface {
+	GetLoginThrottles(ctx context.Context, keys []string) ([]LoginThrottle, error)
+	AddLoginFailure(ctx context.Context, key string, at, since time.Time) (LoginThrottle, error)
+	BlockLogin(ctx context.Context, key string, until time.Time) error
+	ClearLoginThrottle(ctx context.Context, key string) error
+	GetBlockedLogins(ctx context.Context, now time.Time) ([]LoginThrottle, error)
+}
+
+type AuditRepository interface {
+	AddAuditEntry(ctx context.Context, entry AuditEntry) error
+	GetAuditEntries(ctx context.Context, limit int) ([]AuditEntry, error)
+}
+
+type PasswordResetRepository interface {
+	CreatePasswordReset(ctx context.Context, reset PasswordReset) error
+	GetPasswordReset(ctx context.Context, hash string) (PasswordReset, error)
+	UsePasswordReset(ctx context.Context, hash string, at time.Time) error
+	CountPasswordResets(ctx context.Context, userID int64, since time.Time) (int, error)
+	DeleteUserPasswordResets(ctx context.Context, userID int64) error
+	DeleteExpiredPasswordResets(ctx context.Context, now time.Time) error
+}
+
+type TwoFactorRepository interface {
+	GetTwoFactor(ctx context.Context, userID int64) (TwoFactor, error)
+	SaveTwoFactor(ctx context.Context, twoFactor TwoFactor) error
+	UseTOTPStep(ctx context.Context, userID int64, step int64) error
+	DeleteTwoFactor(ctx context.Context, userID int64) error
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, hashes []string) error
+	UseRecoveryCode(ctx context.Context, userID int64, hash string, at time.Time) error
+	CountRecoveryCodes(ctx context.Context, userID int64) (int, error)
+	GetRolePolicies(ctx context.Context) ([]RolePolicy, error)
+	SaveRolePolicy(ctx context.Context, policy RolePolicy) error
 }
@@ -0,0 +1,44 @@
+package domain
+
+import "fmt"
+
+// Role grants a user access to parts of the application. Each role includes
+// the permissions of the ones below it: viewer < admin. There is no editor
+// role until the application has prize editing routes to grant it.
+type Role string
+
+const (
+	RoleViewer Role = "viewer"
+	RoleAdmin  Role = "admin"
+)
+
+// Roles lists the roles from least to most privileged.
+var Roles = []Role{RoleViewer, RoleAdmin}
+
+// ParseRole validates a role name.
+func ParseRole(s string) (Role, error) {
+	r := Role(s)
+	if r.rank() < 0 {
+		return "", fmt.Errorf("%w: unknown role %q", ErrInvalidInput, s)
+	}
+	return r, nil
+}
+
+func (r Role) rank() int {
+	for i, known := range Roles {
+		if r == known {
+			return i
+		}
+	}
+	return -1
+}
+
+// Valid reports whether r is a known role.
+func (r Role) Valid() bool {
+	return r.rank() >= 0
+}
+
+// Includes reports whether the role grants the permissions of other.
+func (r Role) Includes(other Role) bool {
+	return r.Valid() && r.rank() >= other.rank()
+}
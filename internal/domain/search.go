@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SearchTerms splits a free-text query into lower-case words made of letters
+// and digits only. Punctuation acts as a separator, so the result is safe to
+// embed in a full-text query.
+func SearchTerms(query string) []string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]bool, len(words))
+	var terms []string
+	for _, w := range words {
+		if !seen[w] {
+			seen[w] = true
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+// Session is a login on one device. The session token refers to it by ID,
+// so deleting the session logs the device out even if the token has not
+// expired yet.
+type Session struct {
+	ID         string
+	UserID     int64
+	Username   string
+	UserAgent  string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+}
+
+// Expired reports whether the session is no longer valid at the given time.
+func (s Session) Expired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
+
+// RefreshToken renews the access token of a session. Only a hash of the
+// token is stored. Each token is used once and replaced by a new one, so a
+// token presented a second time has been copied and the session is ended.
+type RefreshToken struct {
+	Hash      string
+	SessionID string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	// UsedAt is when the token was exchanged, zero while it is unused.
+	UsedAt time.Time
+}
+
+// Expired reports whether the token is no longer valid at the given time.
+func (t RefreshToken) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// Used reports whether the token was already exchanged.
+func (t RefreshToken) Used() bool {
+	return !t.UsedAt.IsZero()
+}
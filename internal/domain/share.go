@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// ErrInconsistentShares is returned when the shares of a prize's laureates do
+// not add up to the whole prize.
+var ErrInconsistentShares = errors.New("laureate shares do not sum to one")
+
+// Share is the part of a prize a laureate received, as the denominator of a
+// unit fraction: 3 means a third of the prize. The zero value is unknown.
+type Share int
+
+// MaxShare is the smallest part of a prize ever awarded: a quarter.
+const MaxShare Share = 4
+
+// Valid reports whether the share is between a whole and a quarter of the prize.
+func (s Share) Valid() bool {
+	return s >= 1 && s <= MaxShare
+}
+
+// Rat returns the share as a fraction of the prize, or zero when the share
+// is not valid.
+func (s Share) Rat() *big.Rat {
+	if !s.Valid() {
+		return new(big.Rat)
+	}
+	return big.NewRat(1, int64(s))
+}
+
+// Percent returns the share as a percentage of the prize, rounded down.
+func (s Share) Percent() int {
+	if !s.Valid() {
+		return 0
+	}
+	return 100 / int(s)
+}
+
+// String renders the share as a fraction, e.g. "1/3".
+func (s Share) String() string {
+	return "1/" + strconv.Itoa(int(s))
+}
+
+// UnmarshalJSON accepts the share as a number or, as in the Nobel dataset, a
+// string holding a number.
+func (s *Share) UnmarshalJSON(data []byte) error {
+	var v json.Number
+	if err := json.Unmarshal(data, &v); err != nil {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		v = json.Number(str)
+	}
+	n, err := strconv.Atoi(v.String())
+	if err != nil {
+		return fmt.Errorf("%w: share %q", ErrInvalidInput, v)
+	}
+	*s = Share(n)
+	return nil
+}
+
+// MarshalJSON writes the share as a string, like the Nobel dataset.
+func (s Share) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.Itoa(int(s)))
+}
+
+// ValidateShares checks that every laureate has a valid share and that the
+// shares add up to the whole prize. A prize without laureates (not awarded)
+// has nothing to check.
+func (p Prize) ValidateShares() error {
+	if len(p.Laureates) == 0 {
+		return nil
+	}
+	sum := new(big.Rat)
+	for _, l := range p.Laureates {
+		if !l.Share.Valid() {
+			return fmt.Errorf("%w: laureate %s has share %d", ErrInconsistentShares, l.ID, int(l.Share))
+		}
+		sum.Add(sum, l.Share.Rat())
+	}
+	if sum.Cmp(big.NewRat(1, 1)) != 0 {
+		return fmt.Errorf("%w: total is %s", ErrInconsistentShares, sum.RatString())
+	}
+	return nil
+}
+
+// SplitAmount splits a prize amount, supplied by the caller since the Nobel
+// dataset carries none, between the laureates according to their shares.
+// The parts are in the order of the laureates and add up to the amount: the
+// units left over by the division go to the first laureates. A prize that
+// was not awarded has no parts.
+func (p Prize) SplitAmount(amount int64) ([]int64, error) {
+	if amount < 0 {
+		return nil, fmt.Errorf("%w: negative amount %d", ErrInvalidInput, amount)
+	}
+	if err := p.ValidateShares(); err != nil {
+		return nil, err
+	}
+	if len(p.Laureates) == 0 {
+		return nil, nil
+	}
+	parts := make([]int64, len(p.Laureates))
+	rest := amount
+	for i, l := range p.Laureates {
+		parts[i] = amount / int64(l.Share)
+		rest -= parts[i]
+	}
+	for i := 0; rest > 0; i = (i + 1) % len(parts) {
+		parts[i]++
+		rest--
+	}
+	return parts, nil
+}
@@ -0,0 +1,74 @@
+package domain
+
+// StatsFilter narrows the prize statistics to one category and/or one
+// decade. Zero fields select everything.
+type StatsFilter struct {
+	Category Category
+	// Decade is the first year of the decade, e.g. 1950.
+	Decade int
+}
+
+// Validate rejects unknown categories and years that do not start a decade.
+func (f StatsFilter) Validate() error {
+	if f.Category != "" && !f.Category.Valid() {
+		return ErrInvalidInput
+	}
+	if f.Decade != 0 && (f.Decade < 0 || f.Decade%10 != 0) {
+		return ErrInvalidInput
+	}
+	return nil
+}
+
+// Decade returns the first year of the decade the year belongs to.
+func Decade(year int) int {
+	return year / 10 * 10
+}
+
+// CategoryDecadeCount is the number of prizes awarded in a category during a
+// decade.
+type CategoryDecadeCount struct {
+	Category Category
+	Decade   int
+	Count    int
+}
+
+// SharingCount splits the prizes of a decade between those awarded to a
+// single laureate and those shared.
+type SharingCount struct {
+	Decade int
+	Solo   int
+	Shared int
+}
+
+// LaureateCount is a laureate together with the number of prizes they
+// received.
+type LaureateCount struct {
+	Laureate Laureate
+	Prizes   int
+}
+
+// CategoryAverage totals the prizes of a category and their laureates.
+type CategoryAverage struct {
+	Category  Category
+	Prizes    int
+	Laureates int
+}
+
+// Average is the mean number of laureates per prize.
+func (a CategoryAverage) Average() float64 {
+	if a.Prizes == 0 {
+		return 0
+	}
+	return float64(a.Laureates) / float64(a.Prizes)
+}
+
+// PrizeStats gathers the aggregates shown on the statistics page. Decades
+// lists every decade with prizes, for the decade selector.
+type PrizeStats struct {
+	Filter             StatsFilter
+	Decades            []int
+	PerCategoryDecade  []CategoryDecadeCount
+	SharingPerDecade   []SharingCount
+	MultipleLaureates  []LaureateCount
+	AveragePerCategory []CategoryAverage
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// TwoFactor is a user's authenticator app enrolment. The TOTP secret is
+// stored encrypted. Until the user confirms it with a first code, the
+// enrolment is pending and logins do not ask for a code.
+type TwoFactor struct {
+	UserID          int64
+	EncryptedSecret []byte
+	CreatedAt       time.Time
+	// ConfirmedAt is when the user entered a first valid code, zero while
+	// the enrolment is pending.
+	ConfirmedAt time.Time
+	// LastUsedStep is the time step of the last accepted code, so that a
+	// code cannot be used twice.
+	LastUsedStep int64
+}
+
+// Enabled reports whether logins must be completed with a code.
+func (t TwoFactor) Enabled() bool {
+	return !t.ConfirmedAt.IsZero()
+}
+
+// RolePolicy holds the security requirements of a role.
+type RolePolicy struct {
+	Role             Role
+	RequireTwoFactor bool
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MinPasswordLength is the minimum number of characters of a password.
+const MinPasswordLength = 8
+
+// Validation errors of user accounts. They wrap ErrInvalidInput.
+var (
+	ErrInvalidUsername  = fmt.Errorf("%w: username must be 3 to 32 letters, digits, '.', '_' or '-'", ErrInvalidInput)
+	ErrInvalidEmail     = fmt.Errorf("%w: malformed email address", ErrInvalidInput)
+	ErrPasswordTooShort = fmt.Errorf("%w: password shorter than %d characters", ErrInvalidInput, MinPasswordLength)
+	ErrWeakPassword     = fmt.Errorf("%w: password too easy to guess", ErrInvalidInput)
+	ErrPasswordMismatch = fmt.Errorf("%w: passwords do not match", ErrInvalidInput)
+)
+
+// PassphraseLength is the length from which a password is accepted without
+// mixing kinds of characters.
+const PassphraseLength = 16
+
+// commonPasswords are passwords, or parts of them, tried first by attackers.
+var commonPasswords = []string{
+	"password", "motdepasse", "azerty", "qwerty", "123456", "abcdef",
+	"letmein", "welcome", "bienvenue", "admin", "soleil", "iloveyou",
+}
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{3,32}$`)
+
+// ValidateUsername checks the format of a username.
+func ValidateUsername(username string) error {
+	if !usernamePattern.MatchString(username) {
+		return ErrInvalidUsername
+	}
+	return nil
+}
+
+// ValidateEmail accepts a bare address such as "alice@example.com", without
+// display name or angle brackets.
+func ValidateEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return ErrInvalidEmail
+	}
+	return nil
+}
+
+// ValidatePassword checks a new password before it is hashed.
+func ValidatePassword(password string) error {
+	if utf8.RuneCountInString(password) < MinPasswordLength {
+		return ErrPasswordTooShort
+	}
+	return nil
+}
+
+// CheckPasswordStrength checks a password chosen by its user. Besides the
+// minimum length, it must mix at least three kinds of characters among
+// lowercase, uppercase, digits and symbols, unless it is a passphrase of at
+// least PassphraseLength characters, and must not contain the username,
+// the email's local part or a common password.
+func CheckPasswordStrength(password, username, email string) error {
+	if err := ValidatePassword(password); err != nil {
+		return err
+	}
+
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	kinds := 0
+	for _, present := range []bool{lower, upper, digit, other} {
+		if present {
+			kinds++
+		}
+	}
+	if kinds < 3 && utf8.RuneCountInString(password) < PassphraseLength {
+		return ErrWeakPassword
+	}
+
+	folded := strings.ToLower(password)
+	localPart, _, _ := strings.Cut(email, "@")
+	for _, name := range []string{username, localPart} {
+		if utf8.RuneCountInString(name) >= 3 && strings.Contains(folded, strings.ToLower(name)) {
+			return ErrWeakPassword
+		}
+	}
+	for _, common := range commonPasswords {
+		if strings.Contains(folded, common) {
+			return ErrWeakPassword
+		}
+	}
+	return nil
+}
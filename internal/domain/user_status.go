@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// UserStatus tells whether an account may be used. Only active accounts can
+// log in; a disabled account was turned off by an administrator, a locked
+// one by the application, e.g. after repeated failed logins, and a pending
+// one was registered but its email is not verified yet.
+type UserStatus string
+
+const (
+	StatusActive   UserStatus = "active"
+	StatusDisabled UserStatus = "disabled"
+	StatusLocked   UserStatus = "locked"
+	StatusPending  UserStatus = "pending"
+)
+
+// UserStatuses lists every status.
+var UserStatuses = []UserStatus{StatusActive, StatusDisabled, StatusLocked, StatusPending}
+
+// DisabledByAdminReason is recorded when an administrator disables an
+// account without giving a reason.
+const DisabledByAdminReason = "Désactivé par un administrateur"
+
+// PendingVerificationReason is recorded on registered accounts until their
+// email is verified.
+const PendingVerificationReason = "En attente de vérification de l'email"
+
+// ParseUserStatus validates a status name.
+func ParseUserStatus(s string) (UserStatus, error) {
+	status := UserStatus(s)
+	for _, known := range UserStatuses {
+		if status == known {
+			return status, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unknown user status %q", ErrInvalidInput, s)
+}
+
+// IsActive reports whether the user may log in.
+func (u User) IsActive() bool {
+	return u.CheckActive() == nil
+}
+
+// LockExpired reports whether the account was locked after failed logins
+// and the lock has expired, so that it may be used again.
+func (u User) LockExpired(now time.Time) bool {
+	return u.Status == StatusLocked && !u.LockedUntil.IsZero() && !now.Before(u.LockedUntil)
+}
+
+// CheckActive returns ErrAccountDisabled, ErrAccountLocked or
+// ErrAccountPending for an inactive account. An account locked after
+// failed logins is reported as a *LoginBlockedError until its lock
+// expires, and is active again afterwards.
+func (u User) CheckActive() error {
+	switch u.Status {
+	case StatusActive:
+		return nil
+	case StatusLocked:
+		if u.LockedUntil.IsZero() {
+			return ErrAccountLocked
+		}
+		if u.LockExpired(time.Now()) {
+			return nil
+		}
+		return &LoginBlockedError{Until: u.LockedUntil}
+	case StatusPending:
+		return ErrAccountPending
+	default:
+		return ErrAccountDisabled
+	}
+}